@@ -0,0 +1,41 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("APIKeys", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithAPIKeys(map[string]string{"secret": "alice"})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should accept valid api keys", func() {
+		resp := request(endpoint, "GET", "/", "", http.Header{"X-Api-Key": {"secret"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject missing and invalid api keys", func() {
+		Expect(request(endpoint, "GET", "/", "", nil).Code).To(Equal(http.StatusUnauthorized))
+		Expect(request(endpoint, "GET", "/", "", http.Header{"X-Api-Key": {"wrong"}}).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should not be combined with another auth provider", func() {
+		_, err := NewEndpointWithAuth("test", nil, tokenAuth{}, WithAPIKeys(map[string]string{"secret": "alice"}))
+		Expect(err).To(HaveOccurred())
+	})
+})
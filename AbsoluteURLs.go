@@ -0,0 +1,23 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// WithAbsoluteURLs builds the urls of Location and Link headers from baseURL instead of the request host and scheme,
+// e.g. "https://api.example.com/items" behind a TLS terminating load balancer. baseURL is the public url of the
+// path the endpoint is mounted at. Fails if baseURL isn't an absolute url without query and fragment.
+func WithAbsoluteURLs(baseURL string) Option {
+	return func(endpoint *Endpoint) error {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base url %q: %v", baseURL, err)
+		}
+		if u.Scheme == "" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("base url %q must be an absolute url without query and fragment", baseURL)
+		}
+		endpoint.baseURL = u
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AbsoluteURLs", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithAbsoluteURLs("https://api.example.com/items/")))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should base Location headers on the base url", func() {
+		resp := request(endpoint, "PUT", "/key", `{}`, nil)
+		Expect(resp.Header().Get("Location")).To(Equal("https://api.example.com/items/key"))
+		resp = request(endpoint, "POST", "/", `{}`, nil)
+		Expect(resp.Header().Get("Location")).To(HavePrefix("https://api.example.com/items/"))
+	})
+
+	It("should work below a stripped mount path", func() {
+		handler := http.StripPrefix("/api/v1", endpoint)
+		resp := request(handler, "PUT", "/api/v1/key", `{}`, nil)
+		Expect(resp.Header().Get("Location")).To(Equal("https://api.example.com/items/key"))
+	})
+
+	It("should base Link headers on the base url", func() {
+		put(endpoint, "/a", `{}`)
+		put(endpoint, "/b", `{}`)
+		resp := request(endpoint, "GET", "/?limit=1", "", nil)
+		Expect(resp.Header().Get("Link")).To(ContainSubstring("<https://api.example.com/items/?limit=1&offset=1>"))
+	})
+
+	It("should reject relative base urls", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithAbsoluteURLs("/items"))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithAbsoluteURLs("https://api.example.com/items?x=1"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,136 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+type claimsContextKey struct{}
+
+// ContextWithClaims returns a context carrying the claims of an authenticated caller.
+// Authentication middlewares (e.g. JWT validation) use this to pass verified claims to the endpoint.
+func ContextWithClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by ContextWithClaims, or nil
+func ClaimsFromContext(ctx context.Context) map[string]interface{} {
+	claims, _ := ctx.Value(claimsContextKey{}).(map[string]interface{})
+	return claims
+}
+
+// AccessRule restricts the object ids a caller may access.
+// Prefix is a regular expression matched against the requested id, its first capture group
+// must equal the string value of Claim in the caller's claims. The rule applies to the listed
+// Ops, or to all operations if Ops is empty.
+// Example: AccessRule{Prefix: "^users::([^:]+)::", Claim: "sub"} allows the caller with sub "alice"
+// to access "users::alice::profile" but not "users::bob::profile".
+type AccessRule struct {
+	Prefix string
+	Claim  string
+	Ops    []Operation
+}
+
+type accessRule struct {
+	prefix *regexp.Regexp
+	claim  string
+	ops    []Operation
+}
+
+// WithPrefixAccessControl enforces the given rules after the middleware chain ran, so the claims
+// have to be attached to the request context with ContextWithClaims by an authentication middleware.
+// Requests violating a rule get 403 Forbidden. Lists and PATCH / only cover the accessible ids, operations
+// without a caller-chosen id (post, delete_all) are forbidden if a rule applies to them.
+// Fails if a prefix is not a valid regular expression.
+func WithPrefixAccessControl(rules []AccessRule) Option {
+	return func(endpoint *Endpoint) error {
+		for _, rule := range rules {
+			prefix, err := regexp.Compile(rule.Prefix)
+			if err != nil {
+				return fmt.Errorf("invalid access rule prefix %q: %v", rule.Prefix, err)
+			}
+			endpoint.accessRules = append(endpoint.accessRules, accessRule{
+				prefix: prefix,
+				claim:  rule.Claim,
+				ops:    rule.Ops,
+			})
+		}
+		return nil
+	}
+}
+
+func (rule accessRule) appliesTo(op Operation) bool {
+	if len(rule.ops) == 0 {
+		return true
+	}
+	for _, candidate := range rule.ops {
+		if candidate == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule accessRule) allows(claims map[string]interface{}, id string) bool {
+	value, ok := claims[rule.claim].(string)
+	if !ok || value == "" {
+		return false
+	}
+	match := rule.prefix.FindStringSubmatch(id)
+	return len(match) > 1 && match[1] == value
+}
+
+// mayAccess returns whether the caller may perform op on the given id
+func (endpoint *Endpoint) mayAccess(r *http.Request, op Operation, id string) bool {
+	claims := ClaimsFromContext(r.Context())
+	for _, rule := range endpoint.accessRules {
+		if rule.appliesTo(op) && !rule.allows(claims, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// withAccessControl wraps a handler with the check of the access rules
+func (endpoint *Endpoint) withAccessControl(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if len(endpoint.accessRules) == 0 {
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		switch op {
+		case OperationList, OperationQuery, OperationPatchAll:
+			fn(w, r)
+			return
+		case OperationPost, OperationDeleteAll:
+			ids = []string{""}
+		case OperationMove:
+			ids = []string{endpoint.requestID(r), r.URL.Query().Get("to")}
+		default:
+			ids = []string{endpoint.requestID(r)}
+		}
+		for _, id := range ids {
+			if !endpoint.mayAccess(r, op, id) {
+				endpoint.writeError(w, http.StatusForbidden, "access denied")
+				return
+			}
+		}
+		fn(w, r)
+	}
+}
+
+// filterAccessible returns the ids with an allowed prefix the caller may access with an operation on all objects
+func (endpoint *Endpoint) filterAccessible(r *http.Request, op Operation, ids []string) []string {
+	if len(endpoint.accessRules) == 0 && len(endpoint.allowedPrefixes) == 0 {
+		return ids
+	}
+	result := ids[:0]
+	for _, id := range ids {
+		if endpoint.allowedID(id) && endpoint.mayAccess(r, op, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
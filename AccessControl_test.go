@@ -0,0 +1,86 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// claimsFromHeader stands in for a JWT middleware and takes the subject from the X-User header
+func claimsFromHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get("X-User"); user != "" {
+			r = r.WithContext(ContextWithClaims(r.Context(), map[string]interface{}{"sub": user}))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var _ = Describe("AccessControl", func() {
+	var handler http.Handler
+	alice := http.Header{"X-User": []string{"alice"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store,
+			WithMiddlewareChain(claimsFromHeader),
+			WithPrefixAccessControl([]AccessRule{{Prefix: "^users::([^:]+)::", Claim: "sub"}}),
+		))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should allow access to own ids", func() {
+		Expect(request(handler, "PUT", "/users::alice::profile", `{"a":1}`, alice).Code).To(Equal(http.StatusOK))
+		resp := request(handler, "GET", "/users::alice::profile", "", alice)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"a":1}`))
+	})
+
+	It("should forbid access to foreign ids", func() {
+		Expect(request(handler, "PUT", "/users::bob::profile", `{"a":1}`, alice).Code).To(Equal(http.StatusForbidden))
+		Expect(request(handler, "GET", "/users::bob::profile", "", alice).Code).To(Equal(http.StatusForbidden))
+		Expect(request(handler, "GET", "/users::alice::profile", "", nil).Code).To(Equal(http.StatusForbidden))
+		Expect(request(handler, "POST", "/", `{"a":1}`, alice).Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should forbid moving objects to foreign ids", func() {
+		request(handler, "PUT", "/users::alice::profile", `{"a":1}`, alice)
+		resp := request(handler, "MOVE", "/users::alice::profile?to=users::bob::profile", "", alice)
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should only list accessible ids", func() {
+		request(handler, "PUT", "/users::alice::profile", `{"a":1}`, alice)
+		request(handler, "PUT", "/users::bob::profile", `{"a":1}`, http.Header{"X-User": []string{"bob"}})
+		resp := request(handler, "GET", "/", "", alice)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		var ids []string
+		Expect(json.Unmarshal(resp.Body.Bytes(), &ids)).To(Succeed())
+		Expect(ids).To(ConsistOf("users::alice::profile"))
+	})
+
+	It("should only check the listed operations", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store,
+			WithMiddlewareChain(claimsFromHeader),
+			WithPrefixAccessControl([]AccessRule{{Prefix: "^users::([^:]+)::", Claim: "sub", Ops: []Operation{OperationPut}}}),
+		))
+		request(handler, "PUT", "/users::alice::profile", `{"a":1}`, alice)
+		Expect(request(handler, "GET", "/users::alice::profile", "", nil).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid prefixes", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithPrefixAccessControl([]AccessRule{{Prefix: "(", Claim: "sub"}}))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,92 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithAccessLog writes a line in the Combined Log Format of Apache and nginx to w after each request,
+// like `10.0.0.1 - - [10/Oct/2026:13:55:36 +0000] "GET /key HTTP/1.1" 200 13 "-" "curl/8.0"`.
+// It is written in addition to the RequestLogger, lines are never interleaved. Fails if w is nil.
+func WithAccessLog(w io.Writer) Option {
+	return func(endpoint *Endpoint) error {
+		if w == nil {
+			return errors.New("no access log writer given")
+		}
+		endpoint.accessLog = &accessLog{w: w}
+		endpoint.accessLogPath = ""
+		return nil
+	}
+}
+
+// WithAccessLogFile is WithAccessLog appending to the file at path, which is created if it doesn't exist
+func WithAccessLogFile(path string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.accessLog = nil
+		endpoint.accessLogPath = path
+		return nil
+	}
+}
+
+// openAccessLogFile opens the access log file once the options are applied, unless the access log is shared
+func (endpoint *Endpoint) openAccessLogFile() error {
+	if endpoint.accessLogPath == "" || endpoint.accessLog != nil {
+		return nil
+	}
+	file, err := os.OpenFile(endpoint.accessLogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	endpoint.accessLog = &accessLog{w: file}
+	return nil
+}
+
+// accessLog serializes the lines written to the access log
+type accessLog struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// add writes the line of a finished request which started at start
+func (al *accessLog) add(r *http.Request, status int, written int64, start time.Time) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	size := "-"
+	if written > 0 {
+		size = strconv.FormatInt(written, 10)
+	}
+	line := fmt.Sprintf("%v - - [%v] \"%v %v %v\" %v %v \"%v\" \"%v\"\n",
+		dashIfEmpty(host),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, escapeLogValue(r.URL.RequestURI()), r.Proto,
+		status, size,
+		escapeLogValue(dashIfEmpty(r.Referer())),
+		escapeLogValue(dashIfEmpty(r.UserAgent())),
+	)
+	al.mutex.Lock()
+	defer al.mutex.Unlock()
+	io.WriteString(al.w, line)
+}
+
+func dashIfEmpty(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// escapeLogValue escapes quotes and control characters so a value can't break the log line
+func escapeLogValue(value string) string {
+	quoted := strconv.Quote(value)
+	return strings.TrimSuffix(strings.TrimPrefix(quoted, `"`), `"`)
+}
@@ -0,0 +1,79 @@
+package crud_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AccessLog", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+		os.Remove("/tmp/test-access.log")
+	})
+
+	It("should write lines in the combined log format", func() {
+		buf := new(bytes.Buffer)
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithAccessLog(buf)))
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		buf.Reset()
+
+		req := httptest.NewRequest("GET", "/key?pretty=true", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req.Header.Set("Referer", "http://example.com/")
+		req.Header.Set("User-Agent", `curl "quoted"`)
+		endpoint.ServeHTTP(httptest.NewRecorder(), req)
+		Expect(buf.String()).To(MatchRegexp(`^10\.0\.0\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /key\?pretty=true HTTP/1\.1" 200 13 "http://example\.com/" "curl \\"quoted\\""\n$`))
+
+		buf.Reset()
+		req = httptest.NewRequest("GET", "/missing", nil)
+		endpoint.ServeHTTP(httptest.NewRecorder(), req)
+		Expect(buf.String()).To(ContainSubstring(`"GET /missing HTTP/1.1" 404 `))
+		Expect(buf.String()).To(HaveSuffix(`"-" "-"` + "\n"))
+	})
+
+	It("should append to a file without interleaving lines", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithAccessLogFile("/tmp/test-access.log")))
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				get(endpoint, "/")
+			}()
+		}
+		wg.Wait()
+		data, err := ioutil.ReadFile("/tmp/test-access.log")
+		Expect(err).NotTo(HaveOccurred())
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		Expect(lines).To(HaveLen(20))
+		for _, line := range lines {
+			Expect(line).To(MatchRegexp(`"GET / HTTP/1\.1" ` + "200"))
+		}
+	})
+
+	It("should fail without writer", func() {
+		_, err := NewEndpointWithOptions("test", store, WithAccessLog(nil))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithAccessLogFile("/nonexistent/dir/access.log"))
+		Expect(err).To(HaveOccurred())
+	})
+})
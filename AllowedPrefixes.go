@@ -0,0 +1,57 @@
+package crud
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// WithAllowedPrefixes restricts the ids clients can address to the ones starting with one of the prefixes,
+// e.g. "app1::". Other ids get 403, are left out of lists, queries, multi gets and bulk operations, and files
+// with such ids are skipped by imports. Ids generated by POST aren't restricted.
+func WithAllowedPrefixes(prefixes []string) Option {
+	return func(endpoint *Endpoint) error {
+		if len(prefixes) == 0 {
+			return errors.New("no allowed prefixes given")
+		}
+		endpoint.allowedPrefixes = append(endpoint.allowedPrefixes, prefixes...)
+		return nil
+	}
+}
+
+// allowedID returns whether the id starts with an allowed prefix
+func (endpoint *Endpoint) allowedID(id string) bool {
+	if len(endpoint.allowedPrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range endpoint.allowedPrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAllowedPrefixes wraps the handlers of operations on single objects with the prefix check
+func (endpoint *Endpoint) withAllowedPrefixes(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if len(endpoint.allowedPrefixes) == 0 {
+		return fn
+	}
+	switch op {
+	case OperationList, OperationQuery, OperationPost, OperationPatchAll, OperationDeleteAll, OperationWatch, OperationStats, OperationImport:
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids := []string{endpoint.requestID(r)}
+		if op == OperationMove {
+			ids = append(ids, r.URL.Query().Get("to"))
+		}
+		for _, id := range ids {
+			if !endpoint.allowedID(id) {
+				endpoint.writeError(w, http.StatusForbidden, "id "+id+" doesn't start with an allowed prefix")
+				return
+			}
+		}
+		fn(w, r)
+	}
+}
@@ -0,0 +1,80 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AllowedPrefixes", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithAllowedPrefixes([]string{"app1::", "shared::"})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should accept ids with an allowed prefix", func() {
+		code, _ := put(endpoint, "/app1::key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = put(endpoint, "/shared::key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, body := get(endpoint, "/app1::key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should forbid other ids", func() {
+		code, _ := put(endpoint, "/app2::key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusForbidden))
+		code, _ = get(endpoint, "/app2::key")
+		Expect(code).To(Equal(http.StatusForbidden))
+		code, _ = del(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should leave other ids out of lists and bulk operations", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		put(NewEndpoint("test", store), "/app2::secret", `{"s":1}`)
+		put(endpoint, "/app1::key", `{"s":1}`)
+		code, body := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`["app1::key"]`))
+		code, body = get(endpoint, "/?sort=s:asc")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`["app1::key"]`))
+		resp := request(endpoint, "QUERY", "/", `{"filter":{"s":1}}`, http.Header{"Content-Type": {"application/json"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`["app1::key"]`))
+		code, body = get(endpoint, "/?ids=app1::key,app2::secret")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"app1::key":{"s":1},"app2::secret":null}`))
+		resp = request(endpoint, "DELETE", "/", "", http.Header{"Confirm": {"yes-i-know-what-i-am-doing"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(store.Has("test::app2::secret")).To(BeTrue())
+		Expect(store.Has("test::app1::key")).To(BeFalse())
+	})
+
+	It("should not restrict generated ids", func() {
+		code, _ := post(endpoint, "/", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+	})
+
+	It("should fail without prefixes", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithAllowedPrefixes(nil))
+		Expect(err).To(HaveOccurred())
+	})
+})
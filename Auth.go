@@ -0,0 +1,89 @@
+package crud
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/trusch/streamstore"
+)
+
+// AuthProvider authenticates requests and authorizes the operations of the authenticated identities
+type AuthProvider interface {
+	// Authenticate returns the identity of the caller, or an error if the request isn't authenticated
+	Authenticate(r *http.Request) (identity string, err error)
+	// Authorize returns an error if identity may not perform operation on the object id.
+	// The id is empty for operations which don't address a single object, like list.
+	// Requests touching several objects are authorized for each of them as well: a move for
+	// its source and destination, a multi get with get for each id, and patching or deleting
+	// all objects for each affected id, skipping the ids which are denied.
+	Authorize(identity, operation, id string) error
+}
+
+type identityContextKey struct{}
+
+// IdentityFromContext returns the identity of an authenticated request, or ""
+func IdentityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(identityContextKey{}).(string)
+	return identity
+}
+
+// NewEndpointWithAuth constructs an endpoint which authenticates and authorizes every request with auth
+// before the middleware chain runs. Failed authentication gets 401, failed authorization 403, both with
+// a json error body. The identity is available to middlewares, handlers and the request logger via IdentityFromContext.
+func NewEndpointWithAuth(prefix string, store streamstore.Storage, auth AuthProvider, opts ...Option) (*Endpoint, error) {
+	opts = append([]Option{withAuth(auth)}, opts...)
+	return NewEndpointWithOptions(prefix, store, opts...)
+}
+
+func withAuth(auth AuthProvider) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.auth = auth
+		return nil
+	}
+}
+
+// authenticate runs the auth provider. It returns the request carrying the identity,
+// or writes an error response and returns false.
+func (endpoint *Endpoint) authenticate(w http.ResponseWriter, r *http.Request, op Operation) (*http.Request, bool) {
+	if endpoint.auth == nil {
+		return r, true
+	}
+	identity, err := endpoint.auth.Authenticate(r)
+	if err != nil {
+		endpoint.writeEnvelopedError(w, http.StatusUnauthorized, err.Error())
+		return r, false
+	}
+	ids := []string{endpoint.requestID(r)}
+	if op == OperationMove {
+		ids = append(ids, r.URL.Query().Get("to"))
+	}
+	for _, id := range ids {
+		if err = endpoint.auth.Authorize(identity, string(op), id); err != nil {
+			endpoint.writeEnvelopedError(w, http.StatusForbidden, err.Error())
+			return r, false
+		}
+	}
+	return r.WithContext(context.WithValue(r.Context(), identityContextKey{}, identity)), true
+}
+
+// authorized returns whether the authenticated caller may perform op on id
+func (endpoint *Endpoint) authorized(r *http.Request, op Operation, id string) bool {
+	if endpoint.auth == nil {
+		return true
+	}
+	return endpoint.auth.Authorize(IdentityFromContext(r.Context()), string(op), id) == nil
+}
+
+// filterAuthorized returns the ids the authenticated caller may perform op on
+func (endpoint *Endpoint) filterAuthorized(r *http.Request, op Operation, ids []string) []string {
+	if endpoint.auth == nil {
+		return ids
+	}
+	result := ids[:0]
+	for _, id := range ids {
+		if endpoint.authorized(r, op, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,128 @@
+package crud_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// tokenAuth authenticates "Bearer <identity>" and allows identities to access ids starting with their name
+type tokenAuth struct{}
+
+func (tokenAuth) Authenticate(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", errors.New("missing token")
+	}
+	return strings.TrimPrefix(header, "Bearer "), nil
+}
+
+func (tokenAuth) Authorize(identity, operation, id string) error {
+	if operation == "list" || strings.HasPrefix(id, identity+"-") {
+		return nil
+	}
+	return errors.New("access denied")
+}
+
+// prefixAuth works like tokenAuth, but also allows the operations which don't address a single object
+type prefixAuth struct{ tokenAuth }
+
+func (auth prefixAuth) Authorize(identity, operation, id string) error {
+	if id == "" {
+		return nil
+	}
+	return auth.tokenAuth.Authorize(identity, operation, id)
+}
+
+var _ = Describe("Auth", func() {
+	var (
+		handler  http.Handler
+		identity string
+	)
+	alice := http.Header{"Authorization": {"Bearer alice"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithAuth("test", store, tokenAuth{}, WithRequestLogger(func(r *http.Request, status int, duration time.Duration, written int64) {
+			identity = IdentityFromContext(r.Context())
+		})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve authorized requests", func() {
+		Expect(request(handler, "PUT", "/alice-profile", "foobar", alice).Code).To(Equal(http.StatusOK))
+		Expect(request(handler, "GET", "/", "", alice).Code).To(Equal(http.StatusOK))
+		Expect(identity).To(Equal("alice"))
+	})
+
+	It("should return 401 for unauthenticated requests", func() {
+		resp := request(handler, "GET", "/alice-profile", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":{"message":"missing token"},"meta":{}}`))
+	})
+
+	It("should return 403 for unauthorized requests", func() {
+		resp := request(handler, "GET", "/bob-profile", "", alice)
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":{"message":"access denied"},"meta":{}}`))
+	})
+
+	Context("with requests touching several objects", func() {
+		bob := http.Header{"Authorization": {"Bearer bob"}}
+
+		BeforeEach(func() {
+			store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+			Expect(err).NotTo(HaveOccurred())
+			handler = mustEndpoint(NewEndpointWithAuth("test", store, prefixAuth{}))
+			Expect(request(handler, "PUT", "/alice-a", `{"v":1}`, alice).Code).To(Equal(http.StatusOK))
+			Expect(request(handler, "PUT", "/bob-a", `{"v":1}`, bob).Code).To(Equal(http.StatusOK))
+		})
+
+		It("should authorize source and destination of a move", func() {
+			Expect(request(handler, "MOVE", "/alice-a?to=bob-b", "", alice).Code).To(Equal(http.StatusForbidden))
+			Expect(request(handler, "MOVE", "/bob-a?to=alice-b", "", alice).Code).To(Equal(http.StatusForbidden))
+			Expect(request(handler, "GET", "/alice-a", "", alice).Code).To(Equal(http.StatusOK))
+			Expect(request(handler, "GET", "/bob-a", "", bob).Code).To(Equal(http.StatusOK))
+			Expect(request(handler, "MOVE", "/alice-a?to=alice-b", "", alice).Code).To(Equal(http.StatusNoContent))
+		})
+
+		It("should authorize each id of a multi get", func() {
+			resp := request(handler, "GET", "/?ids=alice-a,bob-a", "", alice)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(MatchJSON(`{"alice-a":{"v":1},"bob-a":null}`))
+		})
+
+		It("should only patch the authorized objects", func() {
+			resp := request(handler, "PATCH", "/", `[{"op":"replace","path":"/v","value":2}]`, http.Header{
+				"Authorization": {"Bearer alice"},
+				"Content-Type":  {"application/json-patch+json"},
+			})
+			Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+			Expect(request(handler, "GET", "/alice-a", "", alice).Body.String()).To(MatchJSON(`{"v":2}`))
+			Expect(request(handler, "GET", "/bob-a", "", bob).Body.String()).To(MatchJSON(`{"v":1}`))
+		})
+
+		It("should only delete the authorized objects", func() {
+			resp := request(handler, "DELETE", "/", "", http.Header{
+				"Authorization": {"Bearer alice"},
+				"Confirm":       {"yes-i-know-what-i-am-doing"},
+			})
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(MatchJSON(`{"deleted":1}`))
+			Expect(request(handler, "GET", "/alice-a", "", alice).Code).To(Equal(http.StatusNotFound))
+			Expect(request(handler, "GET", "/bob-a", "", bob).Code).To(Equal(http.StatusOK))
+		})
+	})
+})
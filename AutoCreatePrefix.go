@@ -0,0 +1,33 @@
+package crud
+
+import "fmt"
+
+// Initializable is an optional interface of stores which need a namespace, like a bucket, to be created before use.
+type Initializable interface {
+	// Init creates the namespace of the prefix if it doesn't exist yet
+	Init(prefix string) error
+}
+
+// WithAutoCreatePrefix calls Init with the store prefix at construction time if the store implements Initializable,
+// other stores are used as they are. Construction fails if Init fails.
+func WithAutoCreatePrefix() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.autoCreatePrefix = true
+		return nil
+	}
+}
+
+// initStore initializes the namespace of the store prefix if WithAutoCreatePrefix is set
+func (endpoint *Endpoint) initStore() error {
+	if !endpoint.autoCreatePrefix {
+		return nil
+	}
+	store, ok := endpoint.store.(Initializable)
+	if !ok {
+		return nil
+	}
+	if err := store.Init(endpoint.storePrefix); err != nil {
+		return fmt.Errorf("failed to create prefix %q: %v", endpoint.storePrefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package crud_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// initializableStore records the initialized prefixes
+type initializableStore struct {
+	streamstore.Storage
+	prefixes []string
+	err      error
+}
+
+func (store *initializableStore) Init(prefix string) error {
+	store.prefixes = append(store.prefixes, prefix)
+	return store.err
+}
+
+var _ = Describe("AutoCreatePrefix", func() {
+	var store *initializableStore
+
+	BeforeEach(func() {
+		inner, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = &initializableStore{Storage: inner}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should initialize the store prefix", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithAutoCreatePrefix(), WithStorePrefix("v2_test")))
+		Expect(store.prefixes).To(Equal([]string{"v2_test"}))
+		code, _ := put(endpoint, "/key", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should only initialize with the option", func() {
+		NewEndpoint("test", store)
+		Expect(store.prefixes).To(BeEmpty())
+		mustEndpoint(NewEndpointWithOptions("test", store.Storage, WithAutoCreatePrefix()))
+	})
+
+	It("should fail if the initialization fails", func() {
+		store.err = errors.New("access denied")
+		_, err := NewEndpointWithOptions("test", store, WithAutoCreatePrefix())
+		Expect(err).To(MatchError(ContainSubstring("access denied")))
+	})
+})
@@ -0,0 +1,57 @@
+package crud
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const defaultBinaryContentType = "application/octet-stream"
+
+// WithBinaryMode stores objects as arbitrary bytes together with their content type and size.
+// GET responds with the stored Content-Type, list responds with
+// [{"id":"<id>","content_type":"<type>","size":<n>}] and PATCH is disabled.
+func WithBinaryMode() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.binary = true
+		return nil
+	}
+}
+
+func (endpoint *Endpoint) saveBinarySidecars(r *http.Request, id string, size int64) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = endpoint.fallbackContentType()
+	}
+	if err := endpoint.writeSidecar(id, sidecarContentType, []byte(contentType)); err != nil {
+		return err
+	}
+	return endpoint.writeSidecar(id, sidecarSize, []byte(strconv.FormatInt(size, 10)))
+}
+
+// storedContentType returns the content type from the sidecar or the fallback content type
+func (endpoint *Endpoint) storedContentType(id string) string {
+	contentType, err := endpoint.readSidecar(id, sidecarContentType)
+	if err != nil || len(contentType) == 0 {
+		return endpoint.fallbackContentType()
+	}
+	return string(contentType)
+}
+
+func (endpoint *Endpoint) fallbackContentType() string {
+	if endpoint.defaultContentType != "" {
+		return endpoint.defaultContentType
+	}
+	return defaultBinaryContentType
+}
+
+func (endpoint *Endpoint) storedSize(id string) int64 {
+	data, err := endpoint.readSidecar(id, sidecarSize)
+	if err != nil {
+		return -1
+	}
+	size, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
@@ -0,0 +1,87 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BinaryMode", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithBinaryMode()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	png := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff})
+
+	It("should return the stored content type", func() {
+		resp := request(handler, "PUT", "/image", png, http.Header{"Content-Type": {"image/png"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		resp = request(handler, "GET", "/image", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("image/png"))
+		Expect(resp.Body.String()).To(Equal(png))
+	})
+
+	It("should default to application/octet-stream", func() {
+		put(handler, "/blob", png)
+		resp := request(handler, "GET", "/blob", "", nil)
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/octet-stream"))
+	})
+
+	It("should list ids with content type and size", func() {
+		request(handler, "PUT", "/image", png, http.Header{"Content-Type": {"image/png"}})
+		request(handler, "PUT", "/text", "foobar", http.Header{"Content-Type": {"text/plain"}})
+		code, data := get(handler, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`[
+			{"id":"image","content_type":"image/png","size":6},
+			{"id":"text","content_type":"text/plain","size":6}
+		]`))
+	})
+
+	It("should remove the sidecars on delete", func() {
+		put(handler, "/blob", png)
+		code, _ := del(handler, "/blob")
+		Expect(code).To(Equal(http.StatusOK))
+		_, data := get(handler, "/")
+		list := []interface{}{}
+		Expect(json.Unmarshal([]byte(data), &list)).To(Succeed())
+		Expect(list).To(BeEmpty())
+	})
+
+	It("should not allow patching", func() {
+		put(handler, "/blob", `{"a":1}`)
+		code, _ := patch(handler, "/blob", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should not allow addressing sidecars", func() {
+		resp := request(handler, "PUT", "/image", png, http.Header{"Content-Type": {"image/png"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		for _, sidecar := range []string{"content-type", "size", "tags", "meta"} {
+			path := "/image::" + sidecar
+			Expect(request(handler, "PUT", path, `["x"]`, nil).Code).To(Equal(http.StatusBadRequest))
+			Expect(request(handler, "GET", path, "", nil).Code).To(Equal(http.StatusBadRequest))
+			Expect(request(handler, "PATCH", path, `{"a":1}`, nil).Code).To(Equal(http.StatusBadRequest))
+			Expect(request(handler, "DELETE", path, "", nil).Code).To(Equal(http.StatusBadRequest))
+			Expect(request(handler, "MOVE", "/image?to=other::"+sidecar, "", nil).Code).To(Equal(http.StatusBadRequest))
+		}
+		resp = request(handler, "GET", "/image", "", nil)
+		Expect(resp.Header().Get("Content-Type")).To(Equal("image/png"))
+		Expect(resp.Body.String()).To(Equal(png))
+	})
+})
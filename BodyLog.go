@@ -0,0 +1,185 @@
+package crud
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bodyLogQueueSize is the number of entries buffered for the writer, further entries are dropped
+	bodyLogQueueSize = 1024
+	// bodyLogMaxBody is the number of bytes of each body which are logged
+	bodyLogMaxBody = 1 << 20
+)
+
+// redactedHeaders are logged as "REDACTED"
+var redactedHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "X-Api-Key", "X-Delete-Token"}
+
+// WithBodyLogFile logs the request and response bodies of all requests as json lines to path, for post-mortem debugging.
+// The file is renamed to path.1 and a new one is started when it exceeds maxSize. Entries are written in the background,
+// if the writer can't keep up entries are dropped instead of slowing down requests. Bodies are cut after 1 MiB and
+// credentials in the headers are redacted, but the bodies themselves are logged as they are.
+func WithBodyLogFile(path string, maxSize int64) Option {
+	return func(endpoint *Endpoint) error {
+		if maxSize <= 0 {
+			return errors.New("max body log size must be positive")
+		}
+		endpoint.bodyLogPath = path
+		endpoint.bodyLogMaxSize = maxSize
+		return nil
+	}
+}
+
+// openBodyLogFile starts the body log once the options are applied, unless the body log is shared
+func (endpoint *Endpoint) openBodyLogFile() error {
+	if endpoint.bodyLogPath == "" || endpoint.bodyLog != nil {
+		return nil
+	}
+	bodyLog, err := newBodyLog(endpoint.bodyLogPath, endpoint.bodyLogMaxSize)
+	if err != nil {
+		return err
+	}
+	endpoint.bodyLog = bodyLog
+	return nil
+}
+
+type bodyLogEntry struct {
+	Timestamp       time.Time         `json:"ts"`
+	Operation       Operation         `json:"op"`
+	ID              string            `json:"id,omitempty"`
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Status          int               `json:"status"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	RequestBodyHex  string            `json:"request_body_hex"`
+	ResponseBodyHex string            `json:"response_body_hex"`
+}
+
+// bodyLog writes entries to a rotating file
+type bodyLog struct {
+	path    string
+	maxSize int64
+	entries chan *bodyLogEntry
+	file    *os.File
+	size    int64
+}
+
+func newBodyLog(path string, maxSize int64) (*bodyLog, error) {
+	bl := &bodyLog{
+		path:    path,
+		maxSize: maxSize,
+		entries: make(chan *bodyLogEntry, bodyLogQueueSize),
+	}
+	if err := bl.open(); err != nil {
+		return nil, err
+	}
+	go bl.run()
+	return bl, nil
+}
+
+func (bl *bodyLog) open() error {
+	file, err := os.OpenFile(bl.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	bl.file = file
+	bl.size = info.Size()
+	return nil
+}
+
+// run writes the queued entries, rotating the file when it is full
+func (bl *bodyLog) run() {
+	for entry := range bl.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			log.Warnf("failed to encode body log entry: %v", err)
+			continue
+		}
+		line = append(line, '\n')
+		if bl.size > 0 && bl.size+int64(len(line)) > bl.maxSize {
+			if err = bl.rotate(); err != nil {
+				log.Warnf("failed to rotate body log %v: %v", bl.path, err)
+				continue
+			}
+		}
+		n, err := bl.file.Write(line)
+		bl.size += int64(n)
+		if err != nil {
+			log.Warnf("failed to write body log %v: %v", bl.path, err)
+		}
+	}
+}
+
+func (bl *bodyLog) rotate() error {
+	bl.file.Close()
+	if err := os.Rename(bl.path, bl.path+".1"); err != nil {
+		return err
+	}
+	return bl.open()
+}
+
+// add queues an entry, it is dropped if the queue is full
+func (bl *bodyLog) add(r *http.Request, op Operation, id string, status int, request, response *captureBuffer) {
+	headers := make(map[string]string, len(r.Header))
+	for name := range r.Header {
+		headers[name] = r.Header.Get(name)
+	}
+	for _, name := range redactedHeaders {
+		if _, ok := headers[name]; ok {
+			headers[name] = "REDACTED"
+		}
+	}
+	entry := &bodyLogEntry{
+		Timestamp:       time.Now().UTC(),
+		Operation:       op,
+		ID:              id,
+		Method:          r.Method,
+		URL:             r.URL.String(),
+		Status:          status,
+		RequestHeaders:  headers,
+		RequestBodyHex:  request.hex(),
+		ResponseBodyHex: response.hex(),
+	}
+	select {
+	case bl.entries <- entry:
+	default:
+	}
+}
+
+// captureBuffer keeps the first bodyLogMaxBody bytes written to it
+type captureBuffer struct {
+	mutex sync.Mutex
+	data  []byte
+}
+
+func (buf *captureBuffer) capture(data []byte) {
+	if buf == nil {
+		return
+	}
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+	if free := bodyLogMaxBody - len(buf.data); free > 0 {
+		if len(data) > free {
+			data = data[:free]
+		}
+		buf.data = append(buf.data, data...)
+	}
+}
+
+func (buf *captureBuffer) hex() string {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+	return hex.EncodeToString(buf.data)
+}
@@ -0,0 +1,58 @@
+package crud_test
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BodyLog", func() {
+	const logFile = "/tmp/test-body.log"
+
+	readLog := func(path string) string {
+		data, _ := ioutil.ReadFile(path)
+		return string(data)
+	}
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+		os.Remove(logFile)
+		os.Remove(logFile + ".1")
+	})
+
+	It("should log the request and response bodies", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithBodyLogFile(logFile, 1<<20)))
+		request(endpoint, "PUT", "/key", `{"foo":"bar"}`, http.Header{"Authorization": {"Bearer secret"}})
+		Eventually(func() string { return readLog(logFile) }).Should(ContainSubstring(hex.EncodeToString([]byte(`{"foo":"bar"}`))))
+		content := readLog(logFile)
+		Expect(content).To(ContainSubstring(`"op":"put"`))
+		Expect(content).To(ContainSubstring(`"id":"key"`))
+		Expect(content).To(ContainSubstring(hex.EncodeToString([]byte(`"id":"key"`))))
+		Expect(content).To(ContainSubstring(`"Authorization":"REDACTED"`))
+		Expect(content).NotTo(ContainSubstring("secret"))
+	})
+
+	It("should rotate the file", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithBodyLogFile(logFile, 100)))
+		put(endpoint, "/a", `{}`)
+		put(endpoint, "/b", `{}`)
+		Eventually(func() string { return readLog(logFile + ".1") }).Should(ContainSubstring(`"id":"a"`))
+		Eventually(func() string { return readLog(logFile) }).Should(ContainSubstring(`"id":"b"`))
+	})
+
+	It("should reject invalid sizes", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithBodyLogFile(logFile, 0))
+		Expect(err).To(HaveOccurred())
+	})
+})
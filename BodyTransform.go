@@ -0,0 +1,54 @@
+package crud
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// BodyTransformFunc turns a request body into the bytes to store
+type BodyTransformFunc func(op Operation, id string, body []byte) ([]byte, error)
+
+// TransformError is returned by body transforms to tell malformed bodies (400, ClientError set)
+// from failures of the transform itself (500). Other errors are treated as the latter.
+type TransformError struct {
+	ClientError bool
+	Err         error
+}
+
+func (err TransformError) Error() string {
+	return err.Err.Error()
+}
+
+// WithRequestBodyTransform applies fn to the bodies of POST, PUT and PATCH requests before they are processed,
+// e.g. to decompress uploads or to decode base64 content. For POST the id is the generated one.
+func WithRequestBodyTransform(fn BodyTransformFunc) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.bodyTransform = fn
+		return nil
+	}
+}
+
+// transformBody replaces the request body by its transformed version.
+// If this fails an error response is written and false is returned.
+func (endpoint *Endpoint) transformBody(w http.ResponseWriter, r *http.Request, op Operation, id string) bool {
+	if endpoint.bodyTransform == nil {
+		return true
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	data, err = endpoint.bodyTransform(op, id, data)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if transformErr, ok := err.(TransformError); ok && transformErr.ClientError {
+			status = http.StatusBadRequest
+		}
+		endpoint.writeError(w, status, err.Error())
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return true
+}
@@ -0,0 +1,53 @@
+package crud_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestBodyTransform", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithRequestBodyTransform(func(op Operation, id string, body []byte) ([]byte, error) {
+			if id == "broken" {
+				return nil, errors.New("transform failed")
+			}
+			data, err := base64.StdEncoding.DecodeString(string(body))
+			if err != nil {
+				return nil, TransformError{ClientError: true, Err: err}
+			}
+			return data, nil
+		})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should store the transformed body", func() {
+		code, _ := put(handler, "/key", base64.StdEncoding.EncodeToString([]byte(`{"a":1}`)))
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = patch(handler, "/key", base64.StdEncoding.EncodeToString([]byte(`{"b":2}`)))
+		Expect(code).To(Equal(http.StatusOK))
+		_, data := get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should distinguish client and server errors", func() {
+		code, _ := put(handler, "/key", "not base64!")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = put(handler, "/broken", "")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+	})
+})
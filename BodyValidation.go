@@ -0,0 +1,89 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+const (
+	// defaultMaxBodyDepth is the default nesting limit of json request bodies
+	defaultMaxBodyDepth = 64
+	// defaultMaxBodyKeys is the default limit of object keys in json request bodies
+	defaultMaxBodyKeys = 1024
+)
+
+// WithRequestBodyValidation limits the nesting depth and the total number of object keys of the json bodies
+// which are parsed by the endpoint (PATCH, PATCH /, QUERY and conditional DELETE). Bodies are scanned before
+// they are unmarshaled and rejected with 400 if they exceed a limit. Without this option the limits are 64 and 1024,
+// 0 disables a limit. YAML and form bodies are checked after decoding, also YAML bodies of PUT and POST.
+// Other bodies of PUT and POST are stored as they are and not scanned.
+func WithRequestBodyValidation(maxDepth int, maxKeys int) Option {
+	return func(endpoint *Endpoint) error {
+		if maxDepth < 0 || maxKeys < 0 {
+			return fmt.Errorf("invalid body limits depth %v, keys %v", maxDepth, maxKeys)
+		}
+		endpoint.maxBodyDepth, endpoint.maxBodyKeys = maxDepth, maxKeys
+		return nil
+	}
+}
+
+// checkJSONLimits scans a json document for the depth and key limits.
+// Syntax errors are left to the unmarshaling, which reports them as such.
+func (endpoint *Endpoint) checkJSONLimits(data []byte) error {
+	type container struct {
+		object  bool
+		wantKey bool
+	}
+	var stack []*container
+	keys := 0
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		var top *container
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if _, ok := token.(string); ok && top != nil && top.object && top.wantKey {
+			top.wantKey = false
+			if keys++; endpoint.maxBodyKeys > 0 && keys > endpoint.maxBodyKeys {
+				return fmt.Errorf("json body has more than %v keys", endpoint.maxBodyKeys)
+			}
+			continue
+		}
+		switch token {
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		if top != nil && top.object {
+			top.wantKey = true
+		}
+		if delim, ok := token.(json.Delim); ok {
+			stack = append(stack, &container{object: delim == '{', wantKey: delim == '{'})
+			if endpoint.maxBodyDepth > 0 && len(stack) > endpoint.maxBodyDepth {
+				return fmt.Errorf("json body is nested deeper than %v levels", endpoint.maxBodyDepth)
+			}
+		}
+	}
+}
+
+// decodeJSONBody reads a json request body, checks the limits and unmarshals it into v
+func (endpoint *Endpoint) decodeJSONBody(body io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if err = endpoint.checkJSONLimits(data); err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return &unmarshalError{err: err}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package crud_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BodyValidation", func() {
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a":`, depth-1) + "{}" + strings.Repeat("}", depth-1)
+	}
+	flat := func(keys int) string {
+		fields := make([]string, keys)
+		for i := range fields {
+			fields[i] = fmt.Sprintf(`"k%v":%v`, i, i)
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	}
+
+	endpointWith := func(opts ...Option) *Endpoint {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, opts...))
+		put(endpoint, "/key", `{}`)
+		return endpoint
+	}
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should apply the default limits", func() {
+		endpoint := endpointWith()
+		code, _ := patch(endpoint, "/key", nested(64))
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = patch(endpoint, "/key", nested(65))
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = patch(endpoint, "/key", flat(1024))
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = patch(endpoint, "/key", flat(1025))
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should apply custom limits", func() {
+		endpoint := endpointWith(WithRequestBodyValidation(3, 5))
+		code, _ := patch(endpoint, "/key", `{"a":{"b":[{"c":1}]}}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = patch(endpoint, "/key", `{"a":{"b":[1,2,3]}}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = patch(endpoint, "/key", flat(6))
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = patch(endpoint, "/key", `{"a":"b","c":"d"}`)
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should disable limits set to 0", func() {
+		endpoint := endpointWith(WithRequestBodyValidation(0, 0))
+		code, _ := patch(endpoint, "/key", nested(100))
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should apply the limits to YAML and form bodies", func() {
+		endpoint := endpointWith(WithYAMLInput(), WithRequestBodyValidation(3, 5))
+		yaml := http.Header{"Content-Type": {"application/yaml"}}
+		form := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+		Expect(request(endpoint, "PATCH", "/key", "a:\n  b:\n    - c: 1\n", yaml).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(endpoint, "PATCH", "/key", "a:\n  b: 1\n", yaml).Code).To(Equal(http.StatusOK))
+		Expect(request(endpoint, "PUT", "/key", "a:\n  b:\n    - c: 1\n", yaml).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(endpoint, "PUT", "/key", "k0: 0\nk1: 1\nk2: 2\nk3: 3\nk4: 4\nk5: 5\n", yaml).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(endpoint, "PUT", "/key", "a: 1\n", yaml).Code).To(Equal(http.StatusOK))
+		Expect(request(endpoint, "PATCH", "/key", `a={"b":[{"c":1}]}`, form).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(endpoint, "PATCH", "/key", "k0=0&k1=1&k2=2&k3=3&k4=4&k5=5", form).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(endpoint, "PATCH", "/key", "b=2", form).Code).To(Equal(http.StatusOK))
+		_, data := get(endpoint, "/key")
+		Expect(data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should reject negative limits", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithRequestBodyValidation(-1, 10))
+		Expect(err).To(HaveOccurred())
+	})
+})
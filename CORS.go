@@ -0,0 +1,49 @@
+package crud
+
+import (
+	"net/http"
+	"strings"
+)
+
+const corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, MOVE, QUERY"
+
+// WithAllowedOrigins enables CORS for requests from the given origins, "*" allows all origins.
+// Preflight requests are answered with 204 before routing.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.allowedOrigins = append(endpoint.allowedOrigins, origins...)
+		return nil
+	}
+}
+
+func (endpoint *Endpoint) originAllowed(origin string) bool {
+	for _, allowed := range endpoint.allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// cors sets the CORS response headers and answers preflight requests.
+// It returns true if the request has been answered.
+func (endpoint *Endpoint) cors(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(endpoint.allowedOrigins) == 0 || origin == "" {
+		return false
+	}
+	w.Header().Add("Vary", "Origin")
+	if !endpoint.originAllowed(origin) {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if r.Method != "OPTIONS" || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+	if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", strings.TrimSpace(headers))
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
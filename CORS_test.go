@@ -0,0 +1,48 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CORS", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithAllowedOrigins("https://example.com")))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should allow requests from allowed origins", func() {
+		resp := request(endpoint, "GET", "/", "", http.Header{"Origin": {"https://example.com"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://example.com"))
+	})
+
+	It("should not allow other origins", func() {
+		resp := request(endpoint, "GET", "/", "", http.Header{"Origin": {"https://evil.com"}})
+		Expect(resp.Header().Get("Access-Control-Allow-Origin")).To(BeEmpty())
+	})
+
+	It("should answer preflight requests", func() {
+		resp := request(endpoint, "OPTIONS", "/key", "", http.Header{
+			"Origin":                         {"https://example.com"},
+			"Access-Control-Request-Method":  {"PUT"},
+			"Access-Control-Request-Headers": {"Content-Type"},
+		})
+		Expect(resp.Code).To(Equal(http.StatusNoContent))
+		Expect(resp.Header().Get("Access-Control-Allow-Methods")).To(ContainSubstring("PUT"))
+		Expect(resp.Header().Get("Access-Control-Allow-Headers")).To(Equal("Content-Type"))
+	})
+})
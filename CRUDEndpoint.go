@@ -1,211 +1,835 @@
 package crud
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
-	"strings"
+	"net/url"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
-	uuid "github.com/satori/go.uuid"
-	log "github.com/sirupsen/logrus"
 	"github.com/trusch/streamstore"
+	"golang.org/x/time/rate"
 )
 
 // Endpoint is an http.Handler which serves CRUD requests
 type Endpoint struct {
-	router *mux.Router
-	store  streamstore.Storage
-	prefix string
+	router             *mux.Router
+	store              streamstore.Storage
+	prefix             string
+	envelope           bool
+	sortList           bool
+	sortOrder          SortOrder
+	logger             RequestLogger
+	legacyBodies       bool
+	middlewares        []func(http.Handler) http.Handler
+	binary             bool
+	upstream           string
+	client             *http.Client
+	upstreamTimeout    time.Duration
+	semaphore          chan struct{}
+	semTimeout         time.Duration
+	active             int64
+	metrics            *metrics
+	streamingList      bool
+	defaultContentType string
+	strictContentType  bool
+	deleteAllSecret    string
+	objectSizeLimit    int64
+	yamlInput          bool
+	retryOnConflict    int
+	casMutex           sync.Mutex
+	accessRules        []accessRule
+	handlers           map[string]http.Handler
+	computedFields     ComputedFieldsFunc
+	delimiter          string
+	metadata           bool
+	nonces             *nonceCache
+	parent             *Endpoint
+	foreignKey         string
+	cascadeDelete      bool
+	children           []*Endpoint
+	childrenMutex      sync.Mutex
+	bodyTransform      BodyTransformFunc
+	schemas            *SchemaRegistry
+	maxBodySize        int64
+	tracer             Tracer
+	compression        []string
+	auth               AuthProvider
+	watchers           *watchHub
+	keepAlive          time.Duration
+	storeTelemetry     bool
+	deduplicate        bool
+	maxListSize        int
+	maxMultiGet        int
+	readOnly           bool
+	allowedOrigins     []string
+	ttl                time.Duration
+	routePrefix        string
+	customRouter       *mux.Router
+	importConcurrency  int
+	unmarshalError     UnmarshalErrorFunc
+	cache              CacheStore
+	cacheTTL           time.Duration
+	conflictDetection  bool
+	bodyLog            *bodyLog
+	limiter            *rate.Limiter
+	throttleTimeout    time.Duration
+	objectCountLimit   int
+	idParam            string
+	panicHandler       func(id string, recovered interface{})
+	allowedPrefixes    []string
+	eventStore         EventStore
+	idPrefix           string
+	listIDPrefix       bool
+	statsPath          string
+	forwardedHeaders   bool
+	storePrefix        string
+	storeWrappers      []func(streamstore.Storage) streamstore.Storage
+	mirrorUpstream     string
+	mirrorTimeout      time.Duration
+	mirror             *mirror
+	storeBackoff       BackoffPolicy
+	migrations         []Migration
+	listFilter         func(id string, content []byte) bool
+	filterConcurrency  int
+	maxBodyDepth       int
+	maxBodyKeys        int
+	baseURL            *url.URL
+	tenant             string
+	writeMigrations    bool
+	fallback           func(id string) []byte
+	fallbackType       string
+	s3Redirect         *s3Presigner
+	slashRedirect      bool
+	childSpaces        []string
+	hierarchyParent    *Endpoint
+	dryRun             bool
+	readFlights        *readGroup
+	ipAllowList        []*net.IPNet
+	ipDenyList         []*net.IPNet
+	retryAfterMax      time.Duration
+	clientErrors       sync.Map
+	clientErrorsSweep  int64
+	digestAlgorithm    string
+	autoCreatePrefix   bool
+	watchdog           *time.Timer
+	watchdogDelay      time.Duration
+	watchdogAction     func(*Endpoint)
+	listResponse       func(ids []string, r *http.Request) interface{}
+	warmUpConcurrency  int
+	warmUpOnStart      bool
+	accessLog          *accessLog
+	accessLogPath      string
+	bodyLogPath        string
+	bodyLogMaxSize     int64
+	idRegex            *regexp.Regexp
+	tenantBase         *Endpoint
+	maxTenants         int
 }
 
+// Option configures an Endpoint, it returns an error if its arguments are invalid
+type Option func(*Endpoint) error
+
 // ServeHTTP is the function needed to implement http.Handler
 func (endpoint *Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if endpoint.cors(w, r) || endpoint.serveCustom(w, r) || endpoint.redirectSlashes(w, r) {
+		return
+	}
 	endpoint.router.ServeHTTP(w, r)
 	return
 }
 
 // NewEndpoint constructs a new handler instances
-func NewEndpoint(prefix string, store streamstore.Storage) http.Handler {
-	endpoint := &Endpoint{mux.NewRouter(), store, prefix}
-	endpoint.router.Path("/").Methods("POST").HandlerFunc(endpoint.handlePost)
-	endpoint.router.Path("/").Methods("GET").HandlerFunc(endpoint.handleList)
-	endpoint.router.Path("/{id}").Methods("GET").HandlerFunc(endpoint.handleGet)
-	endpoint.router.Path("/{id}").Methods("PUT").HandlerFunc(endpoint.handlePut)
-	endpoint.router.Path("/{id}").Methods("PATCH").HandlerFunc(endpoint.handlePatch)
-	endpoint.router.Path("/{id}").Methods("DELETE").HandlerFunc(endpoint.handleDel)
+func NewEndpoint(prefix string, store streamstore.Storage) *Endpoint {
+	// without options construction can't fail
+	endpoint, _ := NewEndpointWithOptions(prefix, store)
 	return endpoint
 }
 
+// NewEndpointWithOptions constructs a new handler instance and applies the given options.
+// It fails if one of the options is invalid.
+func NewEndpointWithOptions(prefix string, store streamstore.Storage, opts ...Option) (*Endpoint, error) {
+	endpoint := &Endpoint{
+		router:            mux.NewRouter(),
+		store:             store,
+		prefix:            prefix,
+		logger:            DefaultAccessLogger,
+		semTimeout:        defaultMaxConcurrentTimeout,
+		metrics:           newMetrics(),
+		delimiter:         defaultDelimiter,
+		maxListSize:       defaultMaxListSize,
+		maxMultiGet:       defaultMaxMultiGet,
+		importConcurrency: defaultImportConcurrency(),
+		throttleTimeout:   defaultThrottleTimeout,
+		idParam:           defaultIDParam,
+		mirrorTimeout:     defaultMirrorTimeout,
+		upstreamTimeout:   defaultUpstreamTimeout,
+		filterConcurrency: 1,
+		maxBodyDepth:      defaultMaxBodyDepth,
+		maxBodyKeys:       defaultMaxBodyKeys,
+		warmUpConcurrency: defaultWarmUpConcurrency,
+		maxTenants:        defaultMaxTenants,
+	}
+	for _, opt := range opts {
+		if err := opt(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	if endpoint.tenantBase != nil {
+		endpoint.shareResources(endpoint.tenantBase)
+	}
+	if err := endpoint.openAccessLogFile(); err != nil {
+		return nil, err
+	}
+	if err := endpoint.openBodyLogFile(); err != nil {
+		return nil, err
+	}
+	if endpoint.storePrefix == "" {
+		endpoint.storePrefix = prefix
+	}
+	if err := endpoint.validateDelimiter(); err != nil {
+		return nil, err
+	}
+	if endpoint.tenant != "" {
+		endpoint.storePrefix = endpoint.tenant + endpoint.delimiter + endpoint.storePrefix
+	}
+	if err := endpoint.initStore(); err != nil {
+		return nil, err
+	}
+	for _, wrapper := range endpoint.storeWrappers {
+		if endpoint.store = wrapper(endpoint.store); endpoint.store == nil {
+			return nil, errors.New("store wrapper returned no store")
+		}
+	}
+	if endpoint.storeBackoff != nil {
+		endpoint.store = &retryingStorage{Storage: endpoint.store, policy: endpoint.storeBackoff}
+	}
+	if endpoint.cache != nil {
+		endpoint.store = &cachedStorage{Storage: endpoint.store, cache: endpoint.cache, ttl: endpoint.cacheTTL}
+	}
+	if endpoint.storeTelemetry {
+		endpoint.store = &timedStorage{Storage: endpoint.store, metrics: endpoint.metrics}
+	}
+	if endpoint.dryRun {
+		endpoint.store = &dryRunStorage{Storage: endpoint.store}
+	}
+	if endpoint.upstream != "" {
+		endpoint.client = &http.Client{Timeout: endpoint.upstreamTimeout}
+	}
+	if endpoint.mirrorUpstream != "" && endpoint.mirror == nil {
+		endpoint.mirror = newMirror(endpoint.mirrorTimeout, endpoint.metrics)
+	}
+	if endpoint.parent != nil {
+		endpoint.parent.registerChild(endpoint)
+	}
+	endpoint.handle("/", "POST", OperationPost, endpoint.handlePost)
+	endpoint.handle("/", "GET", OperationList, endpoint.handleList)
+	endpoint.handle("/", "QUERY", OperationQuery, endpoint.handleQuery)
+	endpoint.handle("/", "PATCH", OperationPatchAll, endpoint.handlePatchAll)
+	endpoint.handle("/", "DELETE", OperationDeleteAll, endpoint.handleDelAll)
+	if endpoint.watchers != nil {
+		endpoint.handle("/watch", "GET", OperationWatch, endpoint.handleWatch)
+	}
+	if endpoint.statsPath != "" {
+		endpoint.handle(endpoint.statsPath, "GET", OperationStats, endpoint.handleStats)
+	}
+	endpoint.handle("/{id}", "GET", OperationGet, endpoint.handleGet)
+	endpoint.handle("/meta/{id}", "GET", OperationGetMeta, endpoint.handleMeta)
+	endpoint.handle("/import", "PUT", OperationImport, endpoint.handleImport)
+	endpoint.handle("/{id}", "PUT", OperationPut, endpoint.handlePut)
+	endpoint.handle("/{id}", "PATCH", OperationPatch, endpoint.handlePatch)
+	endpoint.handle("/{id}", "DELETE", OperationDelete, endpoint.handleDel)
+	endpoint.handle("/{id}", "MOVE", OperationMove, endpoint.handleMove)
+	endpoint.handle("/{id}/stream", "GET", OperationGet, endpoint.handleStream)
+	endpoint.handle("/{id}/exists", "GET", OperationGetMeta, endpoint.handleExists)
+	endpoint.handle("/{id}/tags", "POST", OperationAddTags, endpoint.handleAddTags)
+	endpoint.handle("/{id}/tags/{tag}", "DELETE", OperationDeleteTag, endpoint.handleDelTag)
+	if endpoint.slashRedirect {
+		endpoint.router.Path(endpoint.routePrefix+endpoint.routeTemplate("/{id}/")).Methods("GET", "HEAD").HandlerFunc(endpoint.handleTrailingSlash)
+	}
+	endpoint.startWatchdog()
+	endpoint.startWarmUp()
+	return endpoint, nil
+}
+
+// handle registers a handler function and wraps it with the configured request processing
+func (endpoint *Endpoint) handle(path, method string, op Operation, fn http.HandlerFunc) {
+	path = endpoint.routeTemplate(path)
+	handler := endpoint.wrap(op, fn)
+	endpoint.router.Path(endpoint.routePrefix + path).Methods(method).Handler(handler)
+	endpoint.registerHandler(method, path, handler)
+}
+
+// wrap applies the request processing which is common to all handlers
+func (endpoint *Endpoint) wrap(op Operation, fn http.HandlerFunc) http.Handler {
+	var handler http.Handler = endpoint.withReadOnly(op, endpoint.withAllowedPrefixes(op, endpoint.withValidID(op, endpoint.withIDRegex(op, endpoint.withAccessControl(op, endpoint.withMirror(op, endpoint.withReplayProtection(op, fn)))))))
+	for i := len(endpoint.middlewares) - 1; i >= 0; i-- {
+		handler = endpoint.middlewares[i](handler)
+	}
+	if len(endpoint.compression) > 0 {
+		handler = endpoint.compress(handler)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		endpoint.resetWatchdog()
+		r = endpoint.forwarded(r)
+		if endpoint.dryRun {
+			w.Header().Set("X-Dry-Run", "true")
+		}
+		sw := newStatusWriter(w)
+		endpoint.trackErrors(sw, r)
+		body := newCountingReader(r.Body)
+		if endpoint.bodyLog != nil {
+			sw.capture, body.capture = new(captureBuffer), new(captureBuffer)
+		}
+		if r.Body != nil {
+			r.Body = body
+			if endpoint.maxBodySize > 0 {
+				r.Body = http.MaxBytesReader(sw, body, endpoint.maxBodySize)
+			}
+		}
+		if endpoint.checkClientIP(sw, r) && endpoint.throttle(sw, r) && endpoint.acquire(sw) {
+			atomic.AddInt64(&endpoint.active, 1)
+			if authenticated, ok := endpoint.authenticate(sw, r, op); ok {
+				r = authenticated
+				endpoint.serve(handler, sw, r)
+			}
+			atomic.AddInt64(&endpoint.active, -1)
+			endpoint.release()
+		}
+		duration := time.Since(start)
+		endpoint.metrics.record(op, sw.status, duration, body.read, sw.written)
+		if endpoint.bodyLog != nil {
+			endpoint.bodyLog.add(r, op, endpoint.requestID(r), sw.status, body.capture, sw.capture)
+		}
+		if endpoint.logger != nil {
+			endpoint.logger(r, sw.status, duration, sw.written)
+		}
+		if endpoint.accessLog != nil {
+			endpoint.accessLog.add(r, sw.status, sw.written, start)
+		}
+	})
+}
+
 func (endpoint *Endpoint) handlePost(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("POST request to %v", r.URL)
 	if r.Body == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("no body supplied"))
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
 		return
 	}
-	id := uuid.NewV4()
-	objectID := fmt.Sprintf("%v::%v", endpoint.prefix, id.String())
-	writer, err := endpoint.store.GetWriter(objectID)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	if !endpoint.checkObjectCount(w) {
 		return
 	}
-	_, err = io.Copy(writer, r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	id, ok := endpoint.postID(w, r)
+	if !ok {
 		return
 	}
-	err = writer.Close()
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	if !endpoint.saveObject(w, r, OperationPost, id) {
 		return
 	}
-	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(id.String()))
+	endpoint.notify(OperationPost, id)
+	endpoint.appendEvent(OperationPost, id, nil, false)
+	endpoint.writeID(w, r, http.StatusCreated, id)
 }
 
 func (endpoint *Endpoint) handleGet(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("GET request to %v", r.URL)
-	vars := mux.Vars(r)
-	id := vars["id"]
-	objectID := fmt.Sprintf("%v::%v", endpoint.prefix, id)
-	if !endpoint.store.Has(objectID) {
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte("object not found"))
+	id := endpoint.requestID(r)
+	objectID := endpoint.objectID(id)
+	if endpoint.isExpired(id) {
+		if !endpoint.writeFallback(w, id) {
+			endpoint.writeError(w, http.StatusNotFound, "object not found")
+		}
+		return
+	}
+	if endpoint.s3Redirect != nil {
+		endpoint.redirectToS3(w, r, id)
+		return
+	}
+	span := endpoint.storeSpan(r, "GetReader", id)
+	defer span.End()
+	reader, err := endpoint.getReader(objectID)
+	if isNotFound(err) && endpoint.upstream != "" {
+		found, fetchErr := endpoint.fetchUpstream(id)
+		if fetchErr != nil {
+			endpoint.writeError(w, http.StatusBadGateway, fetchErr.Error())
+			return
+		}
+		if !found {
+			if !endpoint.writeFallback(w, id) {
+				endpoint.writeError(w, http.StatusNotFound, "object not found")
+			}
+			return
+		}
+		reader, err = endpoint.store.GetReader(objectID)
+	}
+	if isNotFound(err) && endpoint.writeFallback(w, id) {
 		return
 	}
-	reader, err := endpoint.store.GetReader(objectID)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		endpoint.writeStoreError(w, err)
 		return
 	}
-	_, err = io.Copy(w, reader)
+	if endpoint.conflictDetection {
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("ETag", computeETag(content))
+		reader = ioutil.NopCloser(bytes.NewReader(content))
+	}
+	if len(endpoint.migrations) > 0 && !endpoint.binary {
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err == nil {
+			content, err = endpoint.migrateStored(w, objectID, content)
+		}
+		if err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reader = ioutil.NopCloser(bytes.NewReader(content))
+	}
+	if endpoint.computedFields != nil && !endpoint.binary {
+		content, err := endpoint.computeFields(id, reader)
+		reader.Close()
+		if err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reader = ioutil.NopCloser(bytes.NewReader(content))
+	}
+	if endpoint.envelope {
+		endpoint.writeEnvelopedObject(w, id, reader)
+		reader.Close()
+		return
+	}
+	if digest := endpoint.newDigest(); digest != nil {
+		content, err := ioutil.ReadAll(io.TeeReader(reader, digest))
+		reader.Close()
+		if err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		endpoint.setContentDigest(w, digest)
+		reader = ioutil.NopCloser(bytes.NewReader(content))
+	}
+	if endpoint.binary || endpoint.defaultContentType != "" {
+		w.Header().Set("Content-Type", endpoint.storedContentType(id))
+	}
+	n, err := io.Copy(w, reader)
+	span.SetAttribute("io.bytes", n)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	reader.Close()
 }
 
 func (endpoint *Endpoint) handleList(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("GET request to %v", r.URL)
-	keys, err := endpoint.store.List(endpoint.prefix)
+	if r.URL.Query().Get("ids") != "" {
+		endpoint.handleMultiGet(w, r)
+		return
+	}
+	if r.URL.Query().Get("changed_since") != "" {
+		endpoint.handleChangedSince(w, r)
+		return
+	}
+	format, ok := endpoint.listFormat(w, r)
+	if !ok {
+		return
+	}
+	if endpoint.canIterateList(r) {
+		endpoint.writeIteratedList(w, r)
+		return
+	}
+	start := time.Now()
+	span := endpoint.storeSpan(r, "List", "")
+	storeKeys, err := endpoint.store.List(endpoint.storePrefix)
+	span.End()
+	storeDone := time.Now()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	keys := endpoint.idsFromKeys(storeKeys)
+	keys = endpoint.filterAccessible(r, OperationList, keys)
+	keys = endpoint.filterExpired(keys)
+	keys = endpoint.filterByFunc(keys)
+	keys, err = endpoint.filterByCreated(r, keys)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	for id := range keys {
-		keys[id] = strings.Split(keys[id], "::")[1]
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		keys = endpoint.filterByTag(keys, tag)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	encoder := json.NewEncoder(w)
-	encoder.Encode(keys)
+	if field := r.URL.Query().Get("sort"); field != "" {
+		if err = endpoint.sortByField(keys, field); err != nil {
+			endpoint.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		endpoint.sortKeys(keys)
+	}
+	keys, err = endpoint.paginate(w, r, keys)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	keys = endpoint.truncateList(w, keys)
+	if format == "csv" {
+		endpoint.writeCSVList(w, r, keys)
+		return
+	}
+	items := endpoint.listItems(r, keys)
+	setDurationHeader(w, "X-Store-Duration-Ms", storeDone.Sub(start))
+	setDurationHeader(w, "X-Transform-Duration-Ms", time.Since(storeDone))
+	if endpoint.streamingList && endpoint.listResponse == nil {
+		endpoint.writeJSONLines(w, items)
+		return
+	}
+	endpoint.writeTimedList(w, items, len(keys), start)
 }
 
 func (endpoint *Endpoint) handlePut(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("PUT request to %v", r.URL)
 	if r.Body == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("no body supplied"))
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
 		return
 	}
-	vars := mux.Vars(r)
-	id := vars["id"]
-	objectID := fmt.Sprintf("%v::%v", endpoint.prefix, id)
-	writer, err := endpoint.store.GetWriter(objectID)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	id := endpoint.requestID(r)
+	if endpoint.objectCountLimit > 0 && !endpoint.store.Has(endpoint.objectID(id)) && !endpoint.checkObjectCount(w) {
 		return
 	}
-	_, err = io.Copy(writer, r.Body)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	old := endpoint.eventSnapshot(id)
+	if endpoint.conflictDetection {
+		endpoint.casMutex.Lock()
+		if !endpoint.checkVersion(w, r, id) || !endpoint.saveObject(w, r, OperationPut, id) {
+			endpoint.casMutex.Unlock()
+			return
+		}
+		endpoint.setETag(w, id)
+		endpoint.casMutex.Unlock()
+	} else if !endpoint.saveObject(w, r, OperationPut, id) {
 		return
 	}
+	endpoint.notify(OperationPut, id)
+	endpoint.appendEvent(OperationPut, id, old, false)
+	endpoint.writeID(w, r, http.StatusOK, id)
+}
+
+// saveObject copies the request body to the store.
+// If this fails an error response is written and false is returned.
+func (endpoint *Endpoint) saveObject(w http.ResponseWriter, r *http.Request, op Operation, id string) bool {
+	staged := endpoint.stageWrites(r)
+	if !endpoint.decompressBody(w, r) || !endpoint.transformBody(w, r, op, id) {
+		return false
+	}
+	if endpoint.yamlInput && isYAML(r) {
+		if err := endpoint.convertYAMLBody(r); err != nil {
+			endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+			return false
+		}
+	}
+	if !endpoint.checkContentType(w, r) {
+		return false
+	}
+	if !endpoint.checkParent(w, r) {
+		return false
+	}
+	if !endpoint.checkSchema(w, r, id) {
+		return false
+	}
+	if endpoint.deduplicate && op == OperationPut {
+		unchanged, ok := endpoint.isUnchanged(w, r, id)
+		if !ok || unchanged {
+			return ok
+		}
+	}
+	key := endpoint.objectID(id)
+	if staged {
+		key = endpoint.stagingID(id)
+	}
+	span := endpoint.storeSpan(r, "GetWriter", id)
+	defer span.End()
+	writer, err := endpoint.store.GetWriter(key)
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	var (
+		body   io.Reader = r.Body
+		dst    io.Writer = writer
+		buffer *bytes.Buffer
+		digest = endpoint.newDigest()
+	)
+	if endpoint.upstream != "" {
+		buffer = new(bytes.Buffer)
+		body = io.TeeReader(r.Body, buffer)
+	}
+	if endpoint.objectSizeLimit > 0 {
+		body = io.LimitReader(body, endpoint.objectSizeLimit+1)
+	}
+	if digest != nil {
+		dst = io.MultiWriter(writer, digest)
+	}
+	size, err := io.Copy(dst, body)
+	span.SetAttribute("io.bytes", size)
+	if err != nil {
+		writer.Close()
+		if staged {
+			endpoint.discardStaged(key)
+		}
+		if isBodyTooLarge(err) {
+			endpoint.writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return false
+		}
+		if isMalformedBody(err) {
+			endpoint.writeError(w, http.StatusBadRequest, err.Error())
+			return false
+		}
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
 	err = writer.Close()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		return
+		if staged {
+			endpoint.discardStaged(key)
+		}
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	if endpoint.objectSizeLimit > 0 && size > endpoint.objectSizeLimit {
+		endpoint.discardStaged(key)
+		endpoint.writeError(w, http.StatusRequestEntityTooLarge, "object too large")
+		return false
+	}
+	if staged {
+		if err = endpoint.commitStaged(key, id); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return false
+		}
+	}
+	if err = endpoint.saveSidecars(r, id, size); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
 	}
-	w.Write([]byte(id))
+	if buffer != nil {
+		if err = endpoint.writeThrough("PUT", id, buffer.Bytes(), r.Header.Get("Content-Type")); err != nil {
+			endpoint.writeError(w, http.StatusBadGateway, err.Error())
+			return false
+		}
+	}
+	if digest != nil {
+		endpoint.setContentDigest(w, digest)
+	}
+	advertiseUploadEncoding(w)
+	return true
 }
+
 func (endpoint *Endpoint) handleDel(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("DELETE request to %v", r.URL)
-	vars := mux.Vars(r)
-	id := vars["id"]
-	objectID := fmt.Sprintf("%v::%v", endpoint.prefix, id)
-	if !endpoint.store.Has(objectID) {
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte("object not found"))
+	id := endpoint.requestID(r)
+	objectID := endpoint.objectID(id)
+	condition, ok := endpoint.deleteCondition(w, r)
+	if !ok {
 		return
 	}
+	if condition != nil {
+		// serialized with the other compare-and-swap writes
+		endpoint.casMutex.Lock()
+		if !endpoint.matchesCondition(w, id, condition) {
+			endpoint.casMutex.Unlock()
+			return
+		}
+	}
+	old := endpoint.eventSnapshot(id)
+	span := endpoint.storeSpan(r, "Delete", id)
 	err := endpoint.store.Delete(objectID)
+	span.End()
+	if condition != nil {
+		endpoint.casMutex.Unlock()
+	}
+	if isNotFound(err) && endpoint.upstream != "" {
+		endpoint.deleteUpstreamOnly(w, id)
+		return
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		endpoint.writeStoreError(w, err)
 		return
 	}
+	if status, err := endpoint.deleteRelated(id); err != nil {
+		endpoint.writeError(w, status, err.Error())
+		return
+	}
+	endpoint.notify(OperationDelete, id)
+	endpoint.appendEvent(OperationDelete, id, old, true)
 }
 
-func (endpoint *Endpoint) handlePatch(w http.ResponseWriter, r *http.Request) {
-	log.Debugf("PATCH request to %v", r.URL)
-	vars := mux.Vars(r)
-	id := vars["id"]
-	objectID := fmt.Sprintf("%v::%v", endpoint.prefix, id)
-	if !endpoint.store.Has(objectID) {
-		w.WriteHeader(http.StatusNotFound)
-		w.Write([]byte("object not found"))
-		return
+// deleteRelated removes what belongs to a deleted object: its sidecars, the children of cascading deletes
+// and the upstream copy. On failure it returns the status to respond with.
+func (endpoint *Endpoint) deleteRelated(id string) (int, error) {
+	if err := endpoint.deleteSidecars(id); err != nil {
+		return http.StatusInternalServerError, err
 	}
+	if err := endpoint.deleteChildren(id); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if endpoint.upstream != "" {
+		if err := endpoint.writeThrough("DELETE", id, nil, ""); err != nil {
+			return http.StatusBadGateway, err
+		}
+	}
+	return http.StatusOK, nil
+}
 
-	// get old object
-	reader, err := endpoint.store.GetReader(objectID)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+func (endpoint *Endpoint) handlePatch(w http.ResponseWriter, r *http.Request) {
+	if endpoint.binary {
+		endpoint.writeError(w, http.StatusMethodNotAllowed, "patch is not supported in binary mode")
 		return
 	}
-	oldObject := make(map[string]interface{})
-	decoder := json.NewDecoder(reader)
-	if err = decoder.Decode(&oldObject); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	id := endpoint.requestID(r)
+	objectID := endpoint.objectID(id)
+
+	if !endpoint.transformBody(w, r, OperationPatch, id) || !endpoint.checkPatchContentType(w, r) {
 		return
 	}
 
 	// get patch object
-	decoder = json.NewDecoder(r.Body)
+	var err error
 	patchObject := make(map[string]interface{})
-	if err = decoder.Decode(&patchObject); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+	if isYAML(r) {
+		err = endpoint.decodeYAMLObject(r.Body, &patchObject)
+	} else if isForm(r) {
+		err = endpoint.decodeFormObject(r, patchObject)
+	} else {
+		err = endpoint.decodeJSONBody(r.Body, &patchObject)
+	}
+	if _, ok := err.(*unmarshalError); ok {
+		endpoint.writeUnmarshalError(w, err)
 		return
 	}
+	if err != nil {
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return
+	}
+	if endpoint.computedFields != nil {
+		stripComputedFields(patchObject)
+	}
+	if value, ok := patchObject[endpoint.foreignKey]; ok && endpoint.parent != nil && !endpoint.checkForeignKey(w, value) {
+		return
+	}
+
+	var (
+		oldObject map[string]interface{}
+		merged    *bytes.Buffer
+		written   bool
+	)
+	old := endpoint.eventSnapshot(id)
+	for attempt := 0; ; attempt++ {
+		// get old object
+		var etag string
+		span := endpoint.storeSpan(r, "GetReader", id)
+		oldObject, etag, err = endpoint.readJSONObject(objectID)
+		span.End()
+		if _, ok := err.(*unmarshalError); ok {
+			endpoint.writeUnmarshalError(w, err)
+			return
+		}
+		if err != nil {
+			endpoint.writeStoreError(w, err)
+			return
+		}
+
+		// merge objects
+		for key, val := range patchObject {
+			oldObject[key] = val
+		}
 
-	// merge objects
-	for key, val := range patchObject {
-		oldObject[key] = val
+		// encode merged object
+		merged = new(bytes.Buffer)
+		if err = json.NewEncoder(merged).Encode(oldObject); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if endpoint.objectSizeLimit > 0 && int64(merged.Len()) > endpoint.objectSizeLimit {
+			endpoint.writeError(w, http.StatusRequestEntityTooLarge, "object too large")
+			return
+		}
+		if !endpoint.checkSchemaData(w, r, id, merged.Bytes()) {
+			return
+		}
+		if endpoint.retryOnConflict <= 0 {
+			break
+		}
+
+		// optimistic write, retried if the object changed in between
+		err = endpoint.writeIfMatch(objectID, etag, merged.Bytes())
+		if err == nil {
+			written = true
+			break
+		}
+		if err != errPreconditionFailed {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if attempt >= endpoint.retryOnConflict {
+			endpoint.writeError(w, http.StatusConflict, "object was modified concurrently")
+			return
+		}
+		time.Sleep(conflictBackoff(attempt))
 	}
 
 	// save object
-	writer, err := endpoint.store.GetWriter(objectID)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+	if !written {
+		span := endpoint.storeSpan(r, "GetWriter", id)
+		defer span.End()
+		writer, err := endpoint.store.GetWriter(objectID)
+		if err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		span.SetAttribute("io.bytes", int64(merged.Len()))
+		if _, err = writer.Write(merged.Bytes()); err != nil {
+			writer.Close()
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// close before responding, the object is only stored once Close succeeded
+		if err = writer.Close(); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if endpoint.metadata {
+		if err = endpoint.touchMetadata(id); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if endpoint.upstream != "" {
+		if err = endpoint.writeThrough("PUT", id, merged.Bytes(), "application/json"); err != nil {
+			endpoint.writeError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+	}
+	endpoint.notify(OperationPatch, id)
+	endpoint.appendEvent(OperationPatch, id, old, false)
+	if prefersMinimal(r) {
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, oldObject, endpoint.objectMeta(id))
 		return
 	}
-	defer writer.Close()
-	encoder := json.NewEncoder(io.MultiWriter(writer, w))
-	encoder.Encode(oldObject)
+	w.Write(merged.Bytes())
 }
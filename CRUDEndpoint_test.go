@@ -2,6 +2,8 @@ package crud_test
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -14,6 +16,29 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// failingCloseStore fails to close writers once armed
+type failingCloseStore struct {
+	streamstore.Storage
+	armed *bool
+}
+
+func (store failingCloseStore) GetWriter(id string) (io.WriteCloser, error) {
+	writer, err := store.Storage.GetWriter(id)
+	if err != nil || !*store.armed {
+		return writer, err
+	}
+	return failingCloser{writer}, nil
+}
+
+type failingCloser struct {
+	io.WriteCloser
+}
+
+func (writer failingCloser) Close() error {
+	writer.WriteCloser.Close()
+	return errors.New("close failed")
+}
+
 var _ = Describe("CRUDEndpoint", func() {
 	var (
 		recorder *httptest.ResponseRecorder
@@ -37,8 +62,10 @@ var _ = Describe("CRUDEndpoint", func() {
 		content := "foobar"
 		code, responseData := post(handler, "/", content)
 		Expect(code).To(Equal(http.StatusCreated))
-		Expect(responseData).NotTo(BeEmpty())
-		code, responseData = get(handler, "/"+responseData)
+		resp := struct{ ID string }{}
+		Expect(json.Unmarshal([]byte(responseData), &resp)).To(Succeed())
+		Expect(resp.ID).NotTo(BeEmpty())
+		code, responseData = get(handler, "/"+resp.ID)
 		Expect(code).To(Equal(http.StatusOK))
 		Expect(responseData).To(Equal(content))
 	})
@@ -47,12 +74,23 @@ var _ = Describe("CRUDEndpoint", func() {
 		content := "foobar"
 		code, responseData := put(handler, "/key", content)
 		Expect(code).To(Equal(http.StatusOK))
-		Expect(responseData).To(Equal("key"))
+		Expect(responseData).To(MatchJSON(`{"id":"key"}`))
 		code, responseData = get(handler, "/key")
 		Expect(code).To(Equal(http.StatusOK))
 		Expect(responseData).To(Equal(content))
 	})
 
+	It("should point the location header to the created object", func() {
+		resp := request(handler, "POST", "/", "foobar", nil)
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+		id := struct{ ID string }{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &id)).To(Succeed())
+		Expect(resp.Header().Get("Location")).To(HaveSuffix("/" + id.ID))
+		resp = request(handler, "PUT", "/key", "foobar", nil)
+		Expect(resp.Header().Get("Location")).To(HaveSuffix("/key"))
+	})
+
 	It("should be possible to list data", func() {
 		content := "foobar"
 		put(handler, "/key1", content)
@@ -98,4 +136,14 @@ var _ = Describe("CRUDEndpoint", func() {
 		handler.ServeHTTP(recorder, req)
 		Expect(recorder.Code).To(Equal(http.StatusNotFound))
 	})
+
+	It("should report failing writes of patched objects", func() {
+		armed := false
+		endpoint := NewEndpoint("test", failingCloseStore{store, &armed})
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		armed = true
+		code, body := patch(endpoint, "/key", `{"foo":"baz"}`)
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		Expect(body).To(ContainSubstring("close failed"))
+	})
 })
@@ -0,0 +1,51 @@
+package crud
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// listFormat returns the format requested with ?format=, "json" and "csv" are supported.
+// Unknown formats get 406 and false is returned.
+func (endpoint *Endpoint) listFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		return "json", true
+	case "csv":
+		return format, true
+	default:
+		endpoint.writeError(w, http.StatusNotAcceptable, "unsupported format "+format)
+		return "", false
+	}
+}
+
+// writeCSVList writes the ids as csv download named after the prefix, one id per line.
+// With ?include_metadata=true and metadata enabled there is an id,created_at,updated_at,size header row
+// and the columns are filled from the sidecars, missing values are left empty.
+func (endpoint *Endpoint) writeCSVList(w http.ResponseWriter, r *http.Request, ids []string) {
+	withMeta := endpoint.metadata && r.URL.Query().Get("include_metadata") == "true"
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+endpoint.prefix+".csv\"")
+	writer := csv.NewWriter(w)
+	if withMeta {
+		writer.Write([]string{"id", "created_at", "updated_at", "size"})
+	}
+	for _, id := range ids {
+		if !withMeta {
+			writer.Write([]string{endpoint.listedID(id)})
+			continue
+		}
+		record := []string{endpoint.listedID(id), "", "", ""}
+		if meta, ok := endpoint.loadMetadata(id); ok {
+			record[1] = meta.CreatedAt.Format(time.RFC3339Nano)
+			record[2] = meta.UpdatedAt.Format(time.RFC3339Nano)
+		}
+		if size := endpoint.storedSize(id); size >= 0 {
+			record[3] = strconv.FormatInt(size, 10)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,60 @@
+package crud_test
+
+import (
+	"encoding/csv"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CSVList", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithMetadata(), WithSortedList(Ascending)))
+		put(endpoint, "/b", `{}`)
+		put(endpoint, "/a", `{}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should list the ids as csv download", func() {
+		resp := request(endpoint, "GET", "/?format=csv", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("text/csv"))
+		Expect(resp.Header().Get("Content-Disposition")).To(Equal(`attachment; filename="test.csv"`))
+		Expect(resp.Body.String()).To(Equal("a\nb\n"))
+	})
+
+	It("should include the metadata if requested", func() {
+		resp := request(endpoint, "GET", "/?format=csv&include_metadata=true", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		records, err := csv.NewReader(strings.NewReader(resp.Body.String())).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(3))
+		Expect(records[0]).To(Equal([]string{"id", "created_at", "updated_at", "size"}))
+		Expect(records[1][0]).To(Equal("a"))
+		_, err = time.Parse(time.RFC3339Nano, records[1][1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records[1][3]).To(BeEmpty())
+	})
+
+	It("should respond with 406 for unknown formats", func() {
+		resp := request(endpoint, "GET", "/?format=xml", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusNotAcceptable))
+		code, data := get(endpoint, "/?format=json")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`["a","b"]`))
+	})
+})
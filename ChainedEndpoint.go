@@ -0,0 +1,131 @@
+package crud
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewChainedEndpoint serves the same resource from multiple endpoints, e.g. a primary and a secondary store.
+// GET and QUERY requests are tried in order and the first successful response is returned. Writes and deletes are sent to
+// all endpoints in parallel and succeed if one endpoint succeeded, if all fail with the same status the first
+// response is returned, otherwise 500. POST generates the id once and creates the object with this id everywhere.
+// Watch streams are served by the first endpoint only.
+func NewChainedEndpoint(endpoints ...*Endpoint) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(endpoints) == 0 {
+			http.Error(w, "no endpoints configured", http.StatusInternalServerError)
+			return
+		}
+		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/watch") {
+			endpoints[0].ServeHTTP(w, r)
+			return
+		}
+		if r.Method == "GET" || r.Method == "HEAD" || r.Method == "QUERY" {
+			serveFirstSuccessful(w, r, endpoints)
+			return
+		}
+		serveAll(w, r, endpoints)
+	})
+}
+
+func successful(resp *httptest.ResponseRecorder) bool {
+	return resp.Code >= 200 && resp.Code < 300
+}
+
+// serveFirstSuccessful tries the endpoints in order, if none succeeds the last response is returned
+func serveFirstSuccessful(w http.ResponseWriter, r *http.Request, endpoints []*Endpoint) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), bodyErrorStatus(err))
+			return
+		}
+	}
+	var resp *httptest.ResponseRecorder
+	for _, endpoint := range endpoints {
+		resp = httptest.NewRecorder()
+		if r.Body != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		endpoint.ServeHTTP(resp, r)
+		if successful(resp) {
+			break
+		}
+	}
+	copyResponse(w, resp, resp.Code)
+}
+
+// serveAll sends the request to all endpoints in parallel
+func serveAll(w http.ResponseWriter, r *http.Request, endpoints []*Endpoint) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), bodyErrorStatus(err))
+			return
+		}
+	}
+	method, path, status := r.Method, r.URL.Path, 0
+	if method == "POST" && strings.HasSuffix(path, "/") {
+		method, path, status = "PUT", path+uuid.NewV4().String(), http.StatusCreated
+	}
+	responses := make([]*httptest.ResponseRecorder, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		req := r.WithContext(r.Context())
+		req.Method = method
+		req.URL = new(url.URL)
+		*req.URL = *r.URL
+		req.URL.Path = path
+		req.RequestURI = ""
+		req.Header = r.Header.Clone()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		wg.Add(1)
+		go func(i int, endpoint *Endpoint, req *http.Request) {
+			defer wg.Done()
+			responses[i] = httptest.NewRecorder()
+			endpoint.ServeHTTP(responses[i], req)
+		}(i, endpoint, req)
+	}
+	wg.Wait()
+	var first *httptest.ResponseRecorder
+	sameStatus := true
+	for i, resp := range responses {
+		if !successful(resp) {
+			log.Warnf("chained %v request to endpoint %v failed with %v", r.Method, endpoints[i].prefix, resp.Code)
+			sameStatus = sameStatus && resp.Code == responses[0].Code
+			continue
+		}
+		if first == nil {
+			first = resp
+		}
+	}
+	switch {
+	case first != nil:
+		if status == 0 {
+			status = first.Code
+		}
+		copyResponse(w, first, status)
+	case sameStatus:
+		copyResponse(w, responses[0], responses[0].Code)
+	default:
+		http.Error(w, "request failed on all endpoints", http.StatusInternalServerError)
+	}
+}
+
+func copyResponse(w http.ResponseWriter, resp *httptest.ResponseRecorder, status int) {
+	for key, values := range resp.Header() {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(status)
+	w.Write(resp.Body.Bytes())
+}
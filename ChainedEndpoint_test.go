@@ -0,0 +1,73 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChainedEndpoint", func() {
+	var (
+		primary, secondary *Endpoint
+		handler            http.Handler
+	)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		primary = NewEndpoint("primary", store)
+		secondary = NewEndpoint("secondary", store)
+		handler = NewChainedEndpoint(primary, secondary)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should fall back to the next endpoint on reads", func() {
+		put(secondary, "/key", `{"from":"secondary"}`)
+		code, body := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"from":"secondary"}`))
+		code, _ = get(handler, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should write to all endpoints with the same id", func() {
+		code, body := post(handler, "/", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		var created map[string]string
+		Expect(json.Unmarshal([]byte(body), &created)).To(Succeed())
+		for _, endpoint := range []*Endpoint{primary, secondary} {
+			code, body = get(endpoint, "/"+created["id"])
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		}
+	})
+
+	It("should succeed if one endpoint succeeds", func() {
+		put(primary, "/key", `{"foo":"bar"}`)
+		code, _ := patch(handler, "/key", `{"baz":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		_, body := get(primary, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"bar","baz":1}`))
+	})
+
+	It("should delete from all endpoints", func() {
+		put(handler, "/key", `{}`)
+		code, _ := del(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		for _, endpoint := range []*Endpoint{primary, secondary} {
+			code, _ = get(endpoint, "/key")
+			Expect(code).To(Equal(http.StatusNotFound))
+		}
+		code, _ = del(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
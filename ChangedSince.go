@@ -0,0 +1,55 @@
+package crud
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// changedEntry is an item of a changed_since list response
+type changedEntry struct {
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleChangedSince serves GET /?changed_since=<RFC 3339 time> for incremental syncs: it lists
+// [{"id":"<id>","updated_at":"<time>"}] for the objects modified after the given time, oldest change first.
+// Every object's meta sidecar has to be read. Without WithMetadata there are no modification times, which is 501.
+func (endpoint *Endpoint) handleChangedSince(w http.ResponseWriter, r *http.Request) {
+	if !endpoint.metadata {
+		endpoint.writeError(w, http.StatusNotImplemented, "changed_since needs metadata to be enabled")
+		return
+	}
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("changed_since"))
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, "invalid changed_since: "+err.Error())
+		return
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ids = endpoint.filterAccessible(r, OperationList, ids)
+	ids = endpoint.filterExpired(ids)
+	ids = endpoint.filterByFunc(ids)
+	entries := []changedEntry{}
+	for _, id := range ids {
+		if meta, ok := endpoint.loadMetadata(id); ok && meta.UpdatedAt.After(since) {
+			entries = append(entries, changedEntry{ID: endpoint.listedID(id), UpdatedAt: meta.UpdatedAt})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].UpdatedAt.Equal(entries[j].UpdatedAt) {
+			return entries[i].UpdatedAt.Before(entries[j].UpdatedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	if endpoint.maxListSize > 0 && len(entries) > endpoint.maxListSize {
+		w.Header().Set("X-Truncated", "true")
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(entries)))
+		entries = entries[:endpoint.maxListSize]
+	}
+	endpoint.writeList(w, entries, len(entries))
+}
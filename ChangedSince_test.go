@@ -0,0 +1,63 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangedSince", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithMetadata()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should list the objects changed since the given time", func() {
+		put(endpoint, "/old", `{}`)
+		put(endpoint, "/changed", `{}`)
+		time.Sleep(10 * time.Millisecond)
+		since := time.Now().UTC()
+		put(endpoint, "/new", `{}`)
+		code, _ := patch(endpoint, "/changed", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+
+		code, data := get(endpoint, "/?changed_since="+url.QueryEscape(since.Format(time.RFC3339Nano)))
+		Expect(code).To(Equal(http.StatusOK))
+		entries := []struct {
+			ID        string    `json:"id"`
+			UpdatedAt time.Time `json:"updated_at"`
+		}{}
+		Expect(json.Unmarshal([]byte(data), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].ID).To(Equal("new"))
+		Expect(entries[1].ID).To(Equal("changed"))
+		Expect(entries[1].UpdatedAt).To(BeTemporally(">=", entries[0].UpdatedAt))
+	})
+
+	It("should reject invalid times", func() {
+		code, _ := get(endpoint, "/?changed_since=yesterday")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should need metadata", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		code, _ := get(NewEndpoint("test", store), "/?changed_since=2020-01-01T00:00:00Z")
+		Expect(code).To(Equal(http.StatusNotImplemented))
+	})
+})
@@ -0,0 +1,145 @@
+package crud
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// WithResponseCompression compresses responses with the best algorithm accepted by the client,
+// brotli ("br") before gzip ("gzip") before no compression. Without arguments both algorithms are enabled.
+// The compression is applied outside of the middleware chain. Fails for unknown algorithms.
+func WithResponseCompression(algorithms ...string) Option {
+	return func(endpoint *Endpoint) error {
+		if len(algorithms) == 0 {
+			algorithms = []string{encodingBrotli, encodingGzip}
+		}
+		enabled := make(map[string]bool)
+		for _, algorithm := range algorithms {
+			if algorithm != encodingBrotli && algorithm != encodingGzip {
+				return fmt.Errorf("unsupported compression algorithm %q", algorithm)
+			}
+			enabled[algorithm] = true
+		}
+		endpoint.compression = nil
+		for _, preferred := range []string{encodingBrotli, encodingGzip} {
+			if enabled[preferred] {
+				endpoint.compression = append(endpoint.compression, preferred)
+			}
+		}
+		return nil
+	}
+}
+
+// negotiateEncoding returns the first of the supported encodings accepted by an Accept-Encoding header, or ""
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	accepted := make(map[string]bool)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = q > 0
+			continue
+		}
+		accepted[name] = q > 0
+	}
+	for _, encoding := range supported {
+		if ok, listed := accepted[encoding]; ok || (!listed && wildcard) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compress wraps a handler with the negotiated response compression
+func (endpoint *Endpoint) compress(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), endpoint.compression)
+		if encoding == "" || r.Method == "HEAD" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.Close()
+		handler.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter compresses everything written to it, unless the response must not have a body
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+	passthrough bool
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	if status == http.StatusNoContent || status == http.StatusNotModified || status < 200 {
+		cw.passthrough = true
+	} else {
+		cw.Header().Del("Content-Length")
+		cw.Header().Set("Content-Encoding", cw.encoding)
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.passthrough {
+		return cw.ResponseWriter.Write(data)
+	}
+	if cw.encoder == nil {
+		if cw.encoding == encodingBrotli {
+			cw.encoder = brotli.NewWriter(cw.ResponseWriter)
+		} else {
+			cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+		}
+	}
+	return cw.encoder.Write(data)
+}
+
+// Flush flushes the compressed data and the underlying writer
+func (cw *compressWriter) Flush() {
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close finishes the compressed stream
+func (cw *compressWriter) Close() error {
+	if cw.encoder == nil {
+		return nil
+	}
+	return cw.encoder.Close()
+}
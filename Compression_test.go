@@ -0,0 +1,66 @@
+package crud_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseCompression", func() {
+	var handler http.Handler
+	content := strings.Repeat("foobar", 100)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithResponseCompression()))
+		put(handler, "/key", content)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should prefer brotli", func() {
+		resp := request(handler, "GET", "/key", "", http.Header{"Accept-Encoding": {"gzip, deflate, br"}})
+		Expect(resp.Header().Get("Content-Encoding")).To(Equal("br"))
+		Expect(resp.Header().Get("Vary")).To(Equal("Accept-Encoding"))
+		data, err := ioutil.ReadAll(brotli.NewReader(resp.Body))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("should fall back to gzip", func() {
+		resp := request(handler, "GET", "/key", "", http.Header{"Accept-Encoding": {"gzip, br;q=0"}})
+		Expect(resp.Header().Get("Content-Encoding")).To(Equal("gzip"))
+		reader, err := gzip.NewReader(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(data)).To(Equal(content))
+	})
+
+	It("should not compress without Accept-Encoding", func() {
+		resp := request(handler, "GET", "/key", "", nil)
+		Expect(resp.Header().Get("Content-Encoding")).To(BeEmpty())
+		Expect(resp.Body.String()).To(Equal(content))
+	})
+
+	It("should only use the enabled algorithms", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithResponseCompression("gzip")))
+		resp := request(handler, "GET", "/key", "", http.Header{"Accept-Encoding": {"br, gzip"}})
+		Expect(resp.Header().Get("Content-Encoding")).To(Equal("gzip"))
+		_, err := NewEndpointWithOptions("test", store, WithResponseCompression("deflate"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,50 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// ComputedFieldsFunc returns a copy of a stored object with additional virtual fields
+type ComputedFieldsFunc func(id string, obj map[string]interface{}) map[string]interface{}
+
+// WithComputedFields injects virtual fields into json objects returned by GET.
+// The function gets the parsed stored object and returns the object to send, the stored object is not changed.
+// Computed fields should start with "_": PATCH removes such fields from the patch object before merging,
+// so clients can send back what they received.
+func WithComputedFields(fn ComputedFieldsFunc) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.computedFields = fn
+		return nil
+	}
+}
+
+// computeFields reads an object and applies the computed fields function.
+// Content which isn't a json object is returned unchanged.
+func (endpoint *Endpoint) computeFields(id string, reader io.Reader) ([]byte, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	obj := make(map[string]interface{})
+	if err = json.Unmarshal(content, &obj); err != nil {
+		return content, nil
+	}
+	buf := new(bytes.Buffer)
+	if err = json.NewEncoder(buf).Encode(endpoint.computedFields(id, obj)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// stripComputedFields removes the fields starting with "_" from a patch object
+func stripComputedFields(patchObject map[string]interface{}) {
+	for key := range patchObject {
+		if strings.HasPrefix(key, "_") {
+			delete(patchObject, key)
+		}
+	}
+}
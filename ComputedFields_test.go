@@ -0,0 +1,49 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ComputedFields", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithComputedFields(func(id string, obj map[string]interface{}) map[string]interface{} {
+			obj["_href"] = "/test/" + id
+			return obj
+		})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should add computed fields to GET responses", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, data := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"a":1,"_href":"/test/key"}`))
+	})
+
+	It("should not store computed fields", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, data := patch(handler, "/key", `{"b":2,"_href":"/elsewhere"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should return non-object content unchanged", func() {
+		put(handler, "/key", `[1,2]`)
+		_, data := get(handler, "/key")
+		Expect(data).To(Equal(`[1,2]`))
+	})
+})
@@ -0,0 +1,60 @@
+package crud
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+)
+
+// deleteCondition reads the json object a DELETE request body may carry, the object is only deleted if the
+// stored object has the same values for all its fields. Requests without body get nil.
+// If the body is invalid an error response is written and false is returned.
+func (endpoint *Endpoint) deleteCondition(w http.ResponseWriter, r *http.Request) (map[string]interface{}, bool) {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil, true
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return nil, false
+	}
+	if len(data) == 0 {
+		return nil, true
+	}
+	if endpoint.binary {
+		endpoint.writeError(w, http.StatusBadRequest, "conditional delete is not supported in binary mode")
+		return nil, false
+	}
+	if err = endpoint.checkJSONLimits(data); err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	condition := make(map[string]interface{})
+	if err = json.Unmarshal(data, &condition); err != nil {
+		endpoint.writeUnmarshalError(w, &unmarshalError{err: err})
+		return nil, false
+	}
+	return condition, true
+}
+
+// matchesCondition returns whether every field of the condition equals the field of the stored object.
+// Otherwise an error response is written, 412 if a field differs.
+func (endpoint *Endpoint) matchesCondition(w http.ResponseWriter, id string, condition map[string]interface{}) bool {
+	stored, _, err := endpoint.readJSONObject(endpoint.objectID(id))
+	if _, ok := err.(*unmarshalError); ok {
+		endpoint.writeUnmarshalError(w, err)
+		return false
+	}
+	if err != nil {
+		endpoint.writeStoreError(w, err)
+		return false
+	}
+	for field, value := range condition {
+		if actual, ok := stored[field]; !ok || !reflect.DeepEqual(actual, value) {
+			endpoint.writeError(w, http.StatusPreconditionFailed, "field "+field+" doesn't match")
+			return false
+		}
+	}
+	return true
+}
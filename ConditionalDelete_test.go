@@ -0,0 +1,53 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConditionalDelete", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+		put(endpoint, "/lock", `{"owner":"alice","token":42}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should delete if the fields match", func() {
+		resp := request(endpoint, "DELETE", "/lock", `{"token":42}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		code, _ := get(endpoint, "/lock")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not delete if a field differs", func() {
+		resp := request(endpoint, "DELETE", "/lock", `{"owner":"alice","token":43}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusPreconditionFailed))
+		resp = request(endpoint, "DELETE", "/lock", `{"missing":true}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusPreconditionFailed))
+		code, _ := get(endpoint, "/lock")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid conditions", func() {
+		resp := request(endpoint, "DELETE", "/lock", `{"token":`, nil)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should delete unconditionally without body", func() {
+		code, _ := del(endpoint, "/lock")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,54 @@
+package crud
+
+import (
+	"net/http"
+)
+
+// WithConflictDetection requires PUT requests to carry an If-Match header with the ETag of the object they replace,
+// GET responses carry the ETag. Requests without the header get 428, requests based on an outdated version get 409.
+// New objects are created with "If-None-Match: *", "If-Match: *" replaces any existing version.
+// Imports only create objects, files of existing ids get 409 in the import results.
+func WithConflictDetection() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.conflictDetection = true
+		return nil
+	}
+}
+
+// checkVersion writes an error response and returns false if the PUT request isn't based on the stored version.
+// The caller has to hold the casMutex until the object is written.
+func (endpoint *Endpoint) checkVersion(w http.ResponseWriter, r *http.Request, id string) bool {
+	ifMatch := r.Header.Get("If-Match")
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifMatch == "" && ifNoneMatch != "*" {
+		endpoint.writeError(w, http.StatusPreconditionRequired, "an If-Match header is required")
+		return false
+	}
+	content, err := endpoint.readContent(endpoint.objectID(id))
+	if err != nil && !isNotFound(err) {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	exists := err == nil
+	switch {
+	case ifMatch == "" && exists:
+		endpoint.writeError(w, http.StatusConflict, "object already exists")
+		return false
+	case ifMatch == "":
+		return true
+	case !exists:
+		endpoint.writeError(w, http.StatusConflict, "object doesn't exist anymore")
+		return false
+	case ifMatch != "*" && ifMatch != computeETag(content):
+		endpoint.writeError(w, http.StatusConflict, "object was modified by another request")
+		return false
+	}
+	return true
+}
+
+// setETag sets the ETag header to the current version of an object
+func (endpoint *Endpoint) setETag(w http.ResponseWriter, id string) {
+	if content, err := endpoint.readContent(endpoint.objectID(id)); err == nil {
+		w.Header().Set("ETag", computeETag(content))
+	}
+}
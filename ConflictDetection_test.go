@@ -0,0 +1,67 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConflictDetection", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithConflictDetection()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should require an If-Match header", func() {
+		code, _ := put(endpoint, "/key", `{}`)
+		Expect(code).To(Equal(http.StatusPreconditionRequired))
+	})
+
+	It("should create objects with If-None-Match", func() {
+		create := http.Header{"If-None-Match": {"*"}}
+		resp := request(endpoint, "PUT", "/key", `{"v":1}`, create)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("ETag")).NotTo(BeEmpty())
+		resp = request(endpoint, "PUT", "/key", `{"v":1}`, create)
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+	})
+
+	It("should reject writes based on outdated versions", func() {
+		request(endpoint, "PUT", "/key", `{"v":1}`, http.Header{"If-None-Match": {"*"}})
+		etag := request(endpoint, "GET", "/key", "", nil).Header().Get("ETag")
+		Expect(etag).NotTo(BeEmpty())
+		resp := request(endpoint, "PUT", "/key", `{"v":2}`, http.Header{"If-Match": {etag}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		resp = request(endpoint, "PUT", "/key", `{"v":3}`, http.Header{"If-Match": {etag}})
+		Expect(resp.Code).To(Equal(http.StatusConflict))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"v":2}`))
+	})
+
+	It("should only create objects by imports", func() {
+		request(endpoint, "PUT", "/key", `{"v":1}`, http.Header{"If-None-Match": {"*"}})
+		resp := request(endpoint, "PUT", "/import", zipArchive(map[string]string{
+			"key.json": `{"v":2}`,
+			"new.json": `{"v":1}`,
+		}), http.Header{"Content-Type": {"application/zip"}})
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		Expect(resp.Body.String()).To(MatchJSON(`{
+			"results": [{"file":"new.json","id":"new","status":200}],
+			"errors": [{"file":"key.json","id":"key","status":409,"error":"object already exists"}]
+		}`))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"v":1}`))
+	})
+})
@@ -0,0 +1,49 @@
+package crud
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+)
+
+// digestAlgorithms maps the supported algorithms to their name in the Content-Digest header (RFC 9530) and constructor
+var digestAlgorithms = map[string]struct {
+	name string
+	new  func() hash.Hash
+}{
+	"sha256": {"sha-256", sha256.New},
+	"sha512": {"sha-512", sha512.New},
+	"md5":    {"md5", md5.New},
+}
+
+// WithBodyHashHeader adds a Content-Digest header (RFC 9530) like "sha-256=:<base64>:" to the responses of
+// POST and PUT with the hash of the stored bytes, and to GET responses with the hash of the returned object,
+// so clients can verify the integrity of uploads and downloads. GET responses have to be buffered for this,
+// enveloped GET responses get no digest. Supported algorithms are "sha256", "sha512" and "md5".
+func WithBodyHashHeader(algorithm string) Option {
+	return func(endpoint *Endpoint) error {
+		if _, ok := digestAlgorithms[algorithm]; !ok {
+			return fmt.Errorf("unsupported hash algorithm %q", algorithm)
+		}
+		endpoint.digestAlgorithm = algorithm
+		return nil
+	}
+}
+
+// newDigest returns a hash of the configured algorithm, or nil if there is none
+func (endpoint *Endpoint) newDigest() hash.Hash {
+	if endpoint.digestAlgorithm == "" {
+		return nil
+	}
+	return digestAlgorithms[endpoint.digestAlgorithm].new()
+}
+
+// setContentDigest sets the Content-Digest header from a hash fed with the content
+func (endpoint *Endpoint) setContentDigest(w http.ResponseWriter, digest hash.Hash) {
+	name := digestAlgorithms[endpoint.digestAlgorithm].name
+	w.Header().Set("Content-Digest", name+"=:"+base64.StdEncoding.EncodeToString(digest.Sum(nil))+":")
+}
@@ -0,0 +1,61 @@
+package crud_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContentDigest", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should emit the digest of uploads and downloads", func() {
+		body := `{"foo":"bar"}`
+		sum := sha256.Sum256([]byte(body))
+		expected := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithBodyHashHeader("sha256")))
+
+		resp := request(endpoint, "PUT", "/key", body, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Digest")).To(Equal(expected))
+		resp = request(endpoint, "POST", "/", body, nil)
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+		Expect(resp.Header().Get("Content-Digest")).To(Equal(expected))
+
+		resp = request(endpoint, "GET", "/key", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(Equal(body))
+		Expect(resp.Header().Get("Content-Digest")).To(Equal(expected))
+	})
+
+	It("should support sha512", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithBodyHashHeader("sha512")))
+		sum := sha512.Sum512([]byte(`{}`))
+		resp := request(endpoint, "PUT", "/key", `{}`, nil)
+		Expect(resp.Header().Get("Content-Digest")).To(Equal("sha-512=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"))
+	})
+
+	It("should fail with unsupported algorithms", func() {
+		_, err := NewEndpointWithOptions("test", store, WithBodyHashHeader("crc32"))
+		Expect(err).To(HaveOccurred())
+	})
+})
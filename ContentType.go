@@ -0,0 +1,44 @@
+package crud
+
+import (
+	"mime"
+	"net/http"
+)
+
+// WithDefaultContentType sets the Content-Type of GET responses for objects without a content type sidecar.
+// This is meant for endpoints which only store a single media type.
+func WithDefaultContentType(ct string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.defaultContentType = ct
+		return nil
+	}
+}
+
+// WithStrictContentType rejects writes with 415 if their Content-Type doesn't match the default content type.
+// Requests without Content-Type are accepted and treated as the default content type.
+func WithStrictContentType() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.strictContentType = true
+		return nil
+	}
+}
+
+// checkContentType writes 415 and returns false if the request violates the strict content type
+func (endpoint *Endpoint) checkContentType(w http.ResponseWriter, r *http.Request) bool {
+	if !endpoint.strictContentType || endpoint.defaultContentType == "" {
+		return true
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || sameMediaType(contentType, endpoint.defaultContentType) {
+		return true
+	}
+	endpoint.writeError(w, http.StatusUnsupportedMediaType, "unsupported content type, expected "+endpoint.defaultContentType)
+	return false
+}
+
+// sameMediaType compares two content types ignoring their parameters
+func sameMediaType(a, b string) bool {
+	mediaTypeA, _, errA := mime.ParseMediaType(a)
+	mediaTypeB, _, errB := mime.ParseMediaType(b)
+	return errA == nil && errB == nil && mediaTypeA == mediaTypeB
+}
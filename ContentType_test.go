@@ -0,0 +1,55 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DefaultContentType", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond with the default content type", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithDefaultContentType("image/png")))
+		put(handler, "/image", "png")
+		resp := request(handler, "GET", "/image", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("image/png"))
+	})
+
+	It("should prefer the content type sidecar", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithBinaryMode(), WithDefaultContentType("image/png")))
+		request(handler, "PUT", "/image", "jpg", http.Header{"Content-Type": {"image/jpeg"}})
+		request(handler, "PUT", "/other", "png", nil)
+		Expect(request(handler, "GET", "/image", "", nil).Header().Get("Content-Type")).To(Equal("image/jpeg"))
+		Expect(request(handler, "GET", "/other", "", nil).Header().Get("Content-Type")).To(Equal("image/png"))
+	})
+
+	It("should reject other content types in strict mode", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithDefaultContentType("application/pdf"), WithStrictContentType()))
+		resp := request(handler, "PUT", "/doc", "pdf", http.Header{"Content-Type": {"text/plain"}})
+		Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+		resp = request(handler, "POST", "/", "pdf", http.Header{"Content-Type": {"text/plain"}})
+		Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+		resp = request(handler, "PUT", "/doc", "pdf", http.Header{"Content-Type": {"application/pdf; charset=binary"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		resp = request(handler, "PUT", "/doc", "pdf", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,22 @@
+package crud
+
+import "io"
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	read    int64
+	capture *captureBuffer
+}
+
+func newCountingReader(body io.ReadCloser) *countingReader {
+	return &countingReader{ReadCloser: body}
+}
+
+// Read counts the read bytes and passes them through
+func (cr *countingReader) Read(data []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(data)
+	cr.read += int64(n)
+	cr.capture.capture(data[:n])
+	return n, err
+}
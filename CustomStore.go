@@ -0,0 +1,20 @@
+package crud
+
+import (
+	"errors"
+
+	"github.com/trusch/streamstore"
+)
+
+// WithCustomStore wraps the store at construction time, e.g. with encryption, compression or retries.
+// Wrappers compose in the order of the options, the last one is applied outermost.
+// Store caches and telemetry are applied on top of the wrapped store.
+func WithCustomStore(wrapper func(streamstore.Storage) streamstore.Storage) Option {
+	return func(endpoint *Endpoint) error {
+		if wrapper == nil {
+			return errors.New("no store wrapper given")
+		}
+		endpoint.storeWrappers = append(endpoint.storeWrappers, wrapper)
+		return nil
+	}
+}
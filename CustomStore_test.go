@@ -0,0 +1,56 @@
+package crud_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingStore records the name of the wrapper for every GetWriter call
+type recordingStore struct {
+	streamstore.Storage
+	name  string
+	calls *[]string
+}
+
+func (store *recordingStore) GetWriter(key string) (io.WriteCloser, error) {
+	*store.calls = append(*store.calls, store.name)
+	return store.Storage.GetWriter(key)
+}
+
+var _ = Describe("CustomStore", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should compose the store wrappers", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		calls := []string{}
+		wrapper := func(name string) func(streamstore.Storage) streamstore.Storage {
+			return func(inner streamstore.Storage) streamstore.Storage {
+				return &recordingStore{Storage: inner, name: name, calls: &calls}
+			}
+		}
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithCustomStore(wrapper("inner")), WithCustomStore(wrapper("outer"))))
+		code, _ := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal([]string{"outer", "inner"}))
+		_, body := get(NewEndpoint("test", store), "/key")
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should fail if a wrapper returns no store", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithCustomStore(func(streamstore.Storage) streamstore.Storage { return nil }))
+		Expect(err).To(HaveOccurred())
+	})
+})
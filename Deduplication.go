@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// WithDeduplication skips writing PUT requests whose body equals the stored object.
+// The response is the same as for a real write. Requests setting X-Tags and, in binary mode,
+// requests changing the content type are always written.
+func WithDeduplication() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.deduplicate = true
+		return nil
+	}
+}
+
+// isUnchanged buffers the request body and reports whether it matches the ETag of the stored object.
+// If reading the body fails an error response is written and ok is false.
+func (endpoint *Endpoint) isUnchanged(w http.ResponseWriter, r *http.Request, id string) (unchanged, ok bool) {
+	if r.Header.Get("X-Tags") != "" {
+		return false, true
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return false, false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	content, err := endpoint.readContent(endpoint.objectID(id))
+	if err != nil || computeETag(content) != computeETag(data) {
+		return false, true
+	}
+	if endpoint.binary {
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = endpoint.fallbackContentType()
+		}
+		return contentType == endpoint.storedContentType(id), true
+	}
+	return true, true
+}
@@ -0,0 +1,55 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deduplication", func() {
+	var endpoint *Endpoint
+
+	writes := func() int64 {
+		return endpoint.Metrics().StoreCallCounts["store.write"]
+	}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithDeduplication(), WithStoreTelemetry()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should skip writing unchanged objects", func() {
+		code, _ := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(writes()).To(BeEquivalentTo(1))
+		code, body := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"id":"key"}`))
+		Expect(writes()).To(BeEquivalentTo(1))
+	})
+
+	It("should write changed objects", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		put(endpoint, "/key", `{"foo":"baz"}`)
+		Expect(writes()).To(BeEquivalentTo(2))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"baz"}`))
+	})
+
+	It("should always write when tags are set", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		resp := request(endpoint, "PUT", "/key", `{"foo":"bar"}`, http.Header{"X-Tags": {"a"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(writes()).To(BeNumerically(">", 1))
+	})
+})
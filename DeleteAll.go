@@ -0,0 +1,84 @@
+package crud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	deleteAllConfirmation = "yes-i-know-what-i-am-doing"
+	deleteAllTokenPeriod  = 60
+)
+
+// WithRequireDeleteAllToken additionally requires DELETE / requests to carry a
+// X-Delete-Token header as generated by DeleteAllToken with the same secret.
+func WithRequireDeleteAllToken(secret string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.deleteAllSecret = secret
+		return nil
+	}
+}
+
+// DeleteAllToken returns the time based HMAC token needed to delete all objects of an endpoint
+// configured with WithRequireDeleteAllToken. A token is valid for one to two minutes.
+func DeleteAllToken(secret string, t time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(t.Unix()/deleteAllTokenPeriod, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (endpoint *Endpoint) validDeleteAllToken(token string) bool {
+	now := time.Now()
+	for _, t := range []time.Time{now, now.Add(-deleteAllTokenPeriod * time.Second)} {
+		if hmac.Equal([]byte(token), []byte(DeleteAllToken(endpoint.deleteAllSecret, t))) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDelAll deletes all objects like a DELETE of each id, with their sidecars, cascading deletes and upstream copies.
+// The request must carry the header "Confirm: yes-i-know-what-i-am-doing".
+func (endpoint *Endpoint) handleDelAll(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Confirm") != deleteAllConfirmation {
+		endpoint.writeError(w, http.StatusBadRequest, "deleting all objects requires the header 'Confirm: "+deleteAllConfirmation+"'")
+		return
+	}
+	if endpoint.deleteAllSecret != "" && !endpoint.validDeleteAllToken(r.Header.Get("X-Delete-Token")) {
+		endpoint.writeError(w, http.StatusForbidden, "invalid or missing X-Delete-Token")
+		return
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ids = endpoint.filterAuthorized(r, OperationDeleteAll, endpoint.filterAccessible(r, OperationDeleteAll, ids))
+	deleted := 0
+	for _, id := range ids {
+		old := endpoint.eventSnapshot(id)
+		if err = endpoint.store.Delete(endpoint.objectID(id)); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if status, err := endpoint.deleteRelated(id); err != nil {
+			endpoint.writeError(w, status, err.Error())
+			return
+		}
+		endpoint.appendEvent(OperationDeleteAll, id, old, true)
+		deleted++
+	}
+	endpoint.notify(OperationDeleteAll, "")
+	result := map[string]int{"deleted": deleted}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
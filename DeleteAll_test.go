@@ -0,0 +1,102 @@
+package crud_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeleteAll", func() {
+	var (
+		store   streamstore.Storage
+		confirm = "yes-i-know-what-i-am-doing"
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	fill := func(handler http.Handler) {
+		request(handler, "PUT", "/key1", "foobar", http.Header{"X-Tags": {"red"}})
+		put(handler, "/key2", "foobar")
+		put(handler, "/key3", "foobar")
+	}
+
+	It("should require the confirmation header", func() {
+		handler := NewEndpoint("test", store)
+		fill(handler)
+		resp := request(handler, "DELETE", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		resp = request(handler, "DELETE", "/", "", http.Header{"Confirm": {"YES-I-KNOW-WHAT-I-AM-DOING"}})
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		_, data := get(handler, "/")
+		Expect(data).To(MatchJSON(`["key1","key2","key3"]`))
+	})
+
+	It("should delete all objects and sidecars", func() {
+		handler := NewEndpoint("test", store)
+		fill(handler)
+		resp := request(handler, "DELETE", "/", "", http.Header{"Confirm": {confirm}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"deleted":3}`))
+		keys, err := store.List("test")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys).To(BeEmpty())
+	})
+
+	It("should delete children and upstream copies like a delete of each object", func() {
+		posts := NewEndpoint("posts", store)
+		comments := mustEndpoint(NewEndpointWithOptions("comments", store, WithParentResource(posts, "post_id"), WithCascadeDelete()))
+		put(posts, "/p1", `{}`)
+		put(comments, "/c1", `{"post_id":"p1"}`)
+		resp := request(posts, "DELETE", "/", "", http.Header{"Confirm": {confirm}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		code, _ := get(comments, "/c1")
+		Expect(code).To(Equal(http.StatusNotFound))
+
+		upstreamStore, err := uriparser.NewFromURI("file:///tmp/test-upstream", nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll("/tmp/test-upstream")
+		upstream := NewEndpoint("test", upstreamStore)
+		server := httptest.NewServer(upstream)
+		defer server.Close()
+		proxy := mustEndpoint(NewProxyEndpoint(server.URL, store))
+		put(proxy, "/key", "foobar")
+		resp = request(proxy, "DELETE", "/", "", http.Header{"Confirm": {confirm}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		code, _ = get(upstream, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should require a valid token if configured", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithRequireDeleteAllToken("secret")))
+		fill(handler)
+		resp := request(handler, "DELETE", "/", "", http.Header{"Confirm": {confirm}})
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		resp = request(handler, "DELETE", "/", "", http.Header{
+			"Confirm":        {confirm},
+			"X-Delete-Token": {DeleteAllToken("wrong", time.Now())},
+		})
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		resp = request(handler, "DELETE", "/", "", http.Header{
+			"Confirm":        {confirm},
+			"X-Delete-Token": {DeleteAllToken("secret", time.Now())},
+		})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"deleted":3}`))
+	})
+})
@@ -0,0 +1,50 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/trusch/streamstore/base/file"
+)
+
+// defaultDelimiter separates the prefix, the id and sidecar names in store keys
+const defaultDelimiter = "::"
+
+// WithCustomDelimiter replaces the "::" separating the prefix, the id and sidecar names in store keys.
+// Construction fails if the delimiter is empty or part of the prefix, and for file stores also
+// if it contains characters which are unsafe in file names.
+func WithCustomDelimiter(delim string) Option {
+	return func(endpoint *Endpoint) error {
+		if delim == "" {
+			return errors.New("delimiter must not be empty")
+		}
+		endpoint.delimiter = delim
+		return nil
+	}
+}
+
+// validateDelimiter checks a custom delimiter against the prefix and the store after all options were applied.
+// The default delimiter isn't checked to keep existing prefixes working.
+func (endpoint *Endpoint) validateDelimiter() error {
+	if endpoint.delimiter == defaultDelimiter {
+		return nil
+	}
+	if strings.Contains(endpoint.storePrefix, endpoint.delimiter) {
+		return fmt.Errorf("delimiter %q must not be part of the prefix %q", endpoint.delimiter, endpoint.storePrefix)
+	}
+	if _, ok := endpoint.store.(*file.Storage); ok && !safeFileNameChars(endpoint.delimiter) {
+		return fmt.Errorf("delimiter %q is not safe for file names", endpoint.delimiter)
+	}
+	return nil
+}
+
+func safeFileNameChars(s string) bool {
+	for _, r := range s {
+		if r == '/' || r == '\\' || r == '.' || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
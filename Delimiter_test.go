@@ -0,0 +1,46 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CustomDelimiter", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should store objects with the custom delimiter", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithCustomDelimiter("__"), WithBinaryMode()))
+		resp := request(handler, "PUT", "/a::b", "foobar", http.Header{"Content-Type": {"text/plain"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(store.Has("test__a::b")).To(BeTrue())
+		Expect(store.Has("test__a::b__content-type")).To(BeTrue())
+		_, data := get(handler, "/")
+		Expect(data).To(MatchJSON(`[{"id":"a::b","content_type":"text/plain","size":6}]`))
+	})
+
+	It("should reject invalid delimiters", func() {
+		_, err := NewEndpointWithOptions("test", store, WithCustomDelimiter(""))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("my_test", store, WithCustomDelimiter("_"))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithCustomDelimiter("/"))
+		Expect(err).To(HaveOccurred())
+	})
+})
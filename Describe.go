@@ -0,0 +1,150 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Describe returns a human readable, multi-line summary of the endpoint configuration.
+// It is meant for startup logs and debug endpoints, the format may change.
+func (endpoint *Endpoint) Describe() string {
+	buf := new(bytes.Buffer)
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(buf, format+"\n", args...)
+	}
+	line("prefix: %v", endpoint.prefix)
+	if endpoint.storePrefix != endpoint.prefix {
+		line("store prefix: %v", endpoint.storePrefix)
+	}
+	line("store: %v", describeStore(endpoint.store))
+	line("delimiter: %q", endpoint.delimiter)
+	line("middlewares: %v", len(endpoint.middlewares))
+
+	var options []string
+	option := func(enabled bool, format string, args ...interface{}) {
+		if enabled {
+			options = append(options, fmt.Sprintf(format, args...))
+		}
+	}
+	option(endpoint.envelope, "response envelope")
+	option(endpoint.legacyBodies, "legacy response bodies")
+	option(endpoint.sortList, "sorted list (%v)", map[SortOrder]string{Ascending: "ascending", Descending: "descending"}[endpoint.sortOrder])
+	option(endpoint.streamingList, "streaming list")
+	option(endpoint.listResponse != nil, "custom list response")
+	option(endpoint.binary, "binary mode")
+	option(endpoint.upstream != "", "upstream %v (timeout %v)", endpoint.upstream, endpoint.upstreamTimeout)
+	option(endpoint.s3Redirect != nil, "s3 redirect to bucket %v", describeS3Bucket(endpoint.s3Redirect))
+	option(endpoint.mirrorUpstream != "", "mirror %v (timeout %v)", endpoint.mirrorUpstream, endpoint.mirrorTimeout)
+	option(endpoint.defaultContentType != "", "default content type %v", endpoint.defaultContentType)
+	option(endpoint.fallback != nil, "fallback to default content")
+	option(endpoint.strictContentType, "strict content type")
+	option(endpoint.deleteAllSecret != "", "delete all token required")
+	option(endpoint.maxBodySize > 0, "max body size %v bytes", endpoint.maxBodySize)
+	option(endpoint.maxBodyDepth != defaultMaxBodyDepth || endpoint.maxBodyKeys != defaultMaxBodyKeys, "json body limits depth %v, keys %v", endpoint.maxBodyDepth, endpoint.maxBodyKeys)
+	option(endpoint.objectSizeLimit > 0, "object size limit %v bytes", endpoint.objectSizeLimit)
+	option(endpoint.yamlInput, "yaml input")
+	option(len(endpoint.compression) > 0, "response compression %v", strings.Join(endpoint.compression, ", "))
+	option(endpoint.retryOnConflict > 0, "retry on conflict (%v retries)", endpoint.retryOnConflict)
+	option(endpoint.retryAfterMax > 0, "retry-after on errors (max %v)", endpoint.retryAfterMax)
+	option(endpoint.conflictDetection, "conflict detection")
+	option(endpoint.digestAlgorithm != "", "content digest %v", endpoint.digestAlgorithm)
+	option(endpoint.metadata, "metadata")
+	option(endpoint.autoCreatePrefix, "auto create prefix")
+	option(len(endpoint.storeWrappers) > 0, "custom store wrappers (%v)", len(endpoint.storeWrappers))
+	option(endpoint.storeBackoff != nil, "store backoff")
+	option(endpoint.storeTelemetry, "store telemetry")
+	option(endpoint.eventStore != nil, "event store %v", describeStore(endpoint.eventStore))
+	option(endpoint.cache != nil, "store cache (ttl %v)", endpoint.cacheTTL)
+	option(endpoint.warmUpOnStart, "warm up on start (concurrency %v)", endpoint.warmUpConcurrency)
+	option(endpoint.readFlights != nil, "read coalescing")
+	option(endpoint.bodyLog != nil, "body log file %v", describeBodyLog(endpoint.bodyLog))
+	option(endpoint.accessLog != nil, "access log")
+	option(endpoint.deduplicate, "deduplication")
+	option(endpoint.listFilter != nil, "list filter (concurrency %v)", endpoint.filterConcurrency)
+	option(endpoint.maxListSize > 0, "max list size %v", endpoint.maxListSize)
+	option(endpoint.objectCountLimit > 0, "object count limit %v", endpoint.objectCountLimit)
+	option(endpoint.maxMultiGet > 0, "max multi get %v", endpoint.maxMultiGet)
+	option(endpoint.maxTenants != defaultMaxTenants, "max tenants %v", endpoint.maxTenants)
+	option(endpoint.readOnly, "read-only")
+	option(endpoint.dryRun, "dry run")
+	option(len(endpoint.allowedOrigins) > 0, "allowed origins %v", strings.Join(endpoint.allowedOrigins, ", "))
+	option(endpoint.forwardedHeaders, "forwarded headers")
+	option(len(endpoint.ipAllowList) > 0, "ip allow list (%v ranges)", len(endpoint.ipAllowList))
+	option(len(endpoint.ipDenyList) > 0, "ip deny list (%v ranges)", len(endpoint.ipDenyList))
+	option(endpoint.baseURL != nil, "absolute urls %v", endpoint.baseURL)
+	option(len(endpoint.allowedPrefixes) > 0, "allowed prefixes %v", strings.Join(endpoint.allowedPrefixes, ", "))
+	option(endpoint.idRegex != nil, "object id regex %v", endpoint.idRegex)
+	option(endpoint.ttl > 0, "ttl %v", endpoint.ttl)
+	option(endpoint.routePrefix != "", "route prefix %v", endpoint.routePrefix)
+	option(endpoint.slashRedirect, "trailing slash redirect")
+	option(endpoint.idParam != defaultIDParam, "id param %v", endpoint.idParam)
+	option(endpoint.idPrefix != "", "object id prefix %v (listed %v)", endpoint.idPrefix, map[bool]string{true: "with prefix", false: "without prefix"}[endpoint.listIDPrefix])
+	option(endpoint.importConcurrency > 0, "import concurrency %v", endpoint.importConcurrency)
+	option(endpoint.watchers != nil, "watch endpoint")
+	option(endpoint.statsPath != "", "stats endpoint %v", endpoint.statsPath)
+	option(endpoint.keepAlive > 0, "keep-alive %v", endpoint.keepAlive)
+	option(endpoint.watchdog != nil, "watchdog timer %v", endpoint.watchdogDelay)
+	option(endpoint.nonces != nil, "replay protection")
+	option(endpoint.parent != nil, "parent resource %v (foreign key %v)", describeParent(endpoint.parent), endpoint.foreignKey)
+	option(endpoint.cascadeDelete, "cascade delete")
+	option(endpoint.schemas != nil, "object schema registry")
+	option(len(endpoint.migrations) > 0, "schema evolution (%v migrations, writeback %v)", len(endpoint.migrations), endpoint.writeMigrations)
+	option(len(endpoint.accessRules) > 0, "prefix access control (%v rules)", len(endpoint.accessRules))
+	line("options:")
+	if len(options) == 0 {
+		line("  none")
+	}
+	for _, opt := range options {
+		line("  %v", opt)
+	}
+
+	line("hooks:")
+	line("  request logger: %v", endpoint.logger != nil)
+	line("  computed fields: %v", endpoint.computedFields != nil)
+	line("  request body transform: %v", endpoint.bodyTransform != nil)
+	line("  tracer: %v", endpoint.tracer != nil)
+	line("  auth provider: %v", endpoint.auth != nil)
+	line("  unmarshal error: %v", endpoint.unmarshalError != nil)
+	line("  panic recovery: %v", endpoint.panicHandler != nil)
+
+	if endpoint.semaphore != nil {
+		line("max concurrent: %v (timeout %v)", cap(endpoint.semaphore), endpoint.semTimeout)
+	} else {
+		line("max concurrent: unlimited")
+	}
+	if endpoint.limiter != nil {
+		line("throttling: burst %v, every %v (timeout %v)", endpoint.limiter.Burst(), time.Duration(float64(time.Second)/float64(endpoint.limiter.Limit())), endpoint.throttleTimeout)
+	}
+	return buf.String()
+}
+
+// describeStore names a store by its String method or its type
+func describeStore(store interface{}) string {
+	if stringer, ok := store.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", store)
+}
+
+func describeParent(parent *Endpoint) string {
+	if parent == nil {
+		return ""
+	}
+	return parent.prefix
+}
+
+func describeBodyLog(bl *bodyLog) string {
+	if bl == nil {
+		return ""
+	}
+	return bl.path
+}
+
+func describeS3Bucket(p *s3Presigner) string {
+	if p == nil {
+		return ""
+	}
+	return p.bucket + " (" + p.region + ")"
+}
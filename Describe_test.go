@@ -0,0 +1,28 @@
+package crud_test
+
+import (
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Describe", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should describe the configuration", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("posts", store, WithBinaryMode(), WithMaxConcurrent(4)))
+		description := endpoint.Describe()
+		Expect(description).To(ContainSubstring("prefix: posts"))
+		Expect(description).To(ContainSubstring("*file.Storage"))
+		Expect(description).To(ContainSubstring("binary mode"))
+		Expect(description).To(ContainSubstring("max concurrent: 4"))
+	})
+})
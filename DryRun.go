@@ -0,0 +1,42 @@
+package crud
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/trusch/streamstore"
+)
+
+// WithDryRunMode processes all requests as usual but discards every write and delete, e.g. for integration tests
+// and impact analysis. Reads are served from the real store, so a GET after a PUT still returns the old object.
+// POST and PUT respond with the (generated) id, PATCH with the merged object. Upstream write-through, mirroring
+// and the event store are skipped as well. All responses have the header X-Dry-Run: true.
+func WithDryRunMode() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.dryRun = true
+		return nil
+	}
+}
+
+// dryRunStorage reads from the wrapped store and discards all writes and deletes
+type dryRunStorage struct {
+	streamstore.Storage
+}
+
+type discardWriter struct {
+	io.Writer
+}
+
+func (discardWriter) Close() error {
+	return nil
+}
+
+// GetWriter returns a writer discarding all bytes
+func (store *dryRunStorage) GetWriter(id string) (io.WriteCloser, error) {
+	return discardWriter{ioutil.Discard}, nil
+}
+
+// Delete does nothing
+func (store *dryRunStorage) Delete(id string) error {
+	return nil
+}
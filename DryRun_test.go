@@ -0,0 +1,78 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DryRun", func() {
+	var (
+		store    streamstore.Storage
+		endpoint *Endpoint
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		put(NewEndpoint("test", store), "/existing", `{"foo":"bar"}`)
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithDryRunMode()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond as usual without writing", func() {
+		resp := request(endpoint, "POST", "/", `{"foo":"baz"}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+		Expect(resp.Header().Get("X-Dry-Run")).To(Equal("true"))
+		created := struct{ ID string }{}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &created)).To(Succeed())
+		Expect(created.ID).NotTo(BeEmpty())
+		Expect(store.Has("test::" + created.ID)).To(BeFalse())
+
+		code, _ := put(endpoint, "/new", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(store.Has("test::new")).To(BeFalse())
+
+		code, data := patch(endpoint, "/existing", `{"answer":42}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"foo":"bar","answer":42}`))
+
+		code, _ = del(endpoint, "/existing")
+		Expect(code).To(Equal(http.StatusOK))
+		code, data = get(endpoint, "/existing")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should check size limits without writing", func() {
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithDryRunMode(), WithMaxBodySize(100), WithObjectSizeLimit(20)))
+		code, _ := put(endpoint, "/new", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = post(endpoint, "/", `{"foo":"baz"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		code, _ = put(endpoint, "/existing", `{"foo":"a much longer value"}`)
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(store.Has("test::new")).To(BeFalse())
+		code, data := get(endpoint, "/existing")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should mark reads as dry run", func() {
+		resp := request(endpoint, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("X-Dry-Run")).To(Equal("true"))
+		Expect(resp.Body.String()).To(MatchJSON(`["existing"]`))
+	})
+})
@@ -0,0 +1,66 @@
+package crud
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+)
+
+// errPreconditionFailed is returned by writeIfMatch if the stored object doesn't match the expected ETag
+var errPreconditionFailed = errors.New("precondition failed")
+
+// computeETag returns the strong ETag of an object, which is the quoted sha256 of its content
+func computeETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// readContent reads the complete content stored under a key
+func (endpoint *Endpoint) readContent(key string) ([]byte, error) {
+	reader, err := endpoint.store.GetReader(key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// readJSONObject reads and decodes a stored json object together with its ETag
+func (endpoint *Endpoint) readJSONObject(objectID string) (map[string]interface{}, string, error) {
+	content, err := endpoint.readContent(objectID)
+	if err != nil {
+		return nil, "", err
+	}
+	obj := make(map[string]interface{})
+	if err = json.NewDecoder(bytes.NewReader(content)).Decode(&obj); err != nil {
+		return nil, "", &unmarshalError{err: err, stored: true}
+	}
+	return obj, computeETag(content), nil
+}
+
+// writeIfMatch writes data only if the stored object still has the given ETag.
+// The check and the write are serialized within this endpoint only, other processes
+// writing to the same store can still interfere.
+func (endpoint *Endpoint) writeIfMatch(objectID, etag string, data []byte) error {
+	endpoint.casMutex.Lock()
+	defer endpoint.casMutex.Unlock()
+	content, err := endpoint.readContent(objectID)
+	if err != nil {
+		return err
+	}
+	if computeETag(content) != etag {
+		return errPreconditionFailed
+	}
+	writer, err := endpoint.store.GetWriter(objectID)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
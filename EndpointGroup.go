@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/trusch/streamstore"
+)
+
+// NewEndpointGroup constructs one handler serving multiple resources.
+// Each resource is served below /<name>/ by an endpoint with the prefix <name> and the given options.
+// It fails if the options of a resource are invalid.
+func NewEndpointGroup(store streamstore.Storage, resources map[string][]Option) (http.Handler, error) {
+	router := mux.NewRouter()
+	for name, opts := range resources {
+		endpoint, err := NewEndpointWithOptions(name, store, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("resource %v: %v", name, err)
+		}
+		handler := stripResourcePrefix("/"+name, endpoint)
+		router.Path("/" + name).Handler(handler)
+		router.PathPrefix("/" + name + "/").Handler(handler)
+	}
+	return router, nil
+}
+
+// stripResourcePrefix works like http.StripPrefix but maps the bare prefix to "/"
+func stripResourcePrefix(prefix string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+		if r2.URL.Path == "" {
+			r2.URL.Path = "/"
+		}
+		handler.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,69 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EndpointGroup", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustHandler(NewEndpointGroup(store, map[string][]Option{
+			"users": nil,
+			"posts": {WithResponseEnvelope()},
+		}))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve each resource below its own path", func() {
+		code, _ := put(handler, "/users/alice", "foobar")
+		Expect(code).To(Equal(http.StatusOK))
+		code, data := get(handler, "/users/alice")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+		code, _ = get(handler, "/posts/alice")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should list each resource separately", func() {
+		put(handler, "/users/alice", "foobar")
+		put(handler, "/users/bob", "foobar")
+		put(handler, "/posts/first", "foobar")
+		for _, path := range []string{"/users", "/users/"} {
+			code, data := get(handler, path)
+			Expect(code).To(Equal(http.StatusOK))
+			list := []string{}
+			Expect(json.Unmarshal([]byte(data), &list)).To(Succeed())
+			Expect(list).To(ConsistOf("alice", "bob"))
+		}
+	})
+
+	It("should apply resource specific options", func() {
+		put(handler, "/posts/first", `{"a":1}`)
+		_, data := get(handler, "/posts/first")
+		Expect(data).To(MatchJSON(`{"data":{"a":1},"meta":{"id":"first","prefix":"posts"}}`))
+	})
+
+	It("should return 404 for unknown resources", func() {
+		code, _ := get(handler, "/comments/")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should fail for invalid resource options", func() {
+		_, err := NewEndpointGroup(nil, map[string][]Option{"posts": {WithMaxConcurrent(-1)}})
+		Expect(err).To(MatchError(ContainSubstring("posts")))
+	})
+})
@@ -0,0 +1,119 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Event records a successful mutation of an object.
+// OldContent is nil for new objects, NewContent is nil for deleted ones.
+type Event struct {
+	Op         Operation
+	ID         string
+	Prefix     string
+	Timestamp  time.Time
+	OldContent []byte
+	NewContent []byte
+}
+
+// EventStore persists the events of an endpoint, Append is called in the order of the mutations
+type EventStore interface {
+	Append(event Event) error
+}
+
+// EventReader is implemented by event stores which can return the events of an object, it enables Replay
+type EventReader interface {
+	Events(ctx context.Context, prefix, id string) ([]Event, error)
+}
+
+// WithEventStore appends an event to es after each successful object write of POST, PUT, PATCH, DELETE,
+// move, import and PATCH /. DELETE / appends a delete event per object, a move appends one event for the source
+// and one for the destination. The previous content is read before the write, so with concurrent writes to the
+// same object OldContent may lag behind. Failing appends are logged, the response isn't affected.
+func WithEventStore(es EventStore) Option {
+	return func(endpoint *Endpoint) error {
+		if es == nil {
+			return errors.New("no event store given")
+		}
+		endpoint.eventStore = es
+		return nil
+	}
+}
+
+// Replay returns the events of an object in the order they were appended.
+// It fails if no event store is configured or the event store doesn't implement EventReader.
+func (endpoint *Endpoint) Replay(ctx context.Context, id string) ([]Event, error) {
+	reader, ok := endpoint.eventStore.(EventReader)
+	if !ok {
+		return nil, errors.New("the event store can't be read")
+	}
+	return reader.Events(ctx, endpoint.prefix, id)
+}
+
+// eventSnapshot returns the current content of an object for the next event, or nil
+func (endpoint *Endpoint) eventSnapshot(id string) []byte {
+	if endpoint.eventStore == nil {
+		return nil
+	}
+	content, err := endpoint.readContent(endpoint.objectID(id))
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// appendEvent appends an event with the current content of the object, deleted objects get no new content
+func (endpoint *Endpoint) appendEvent(op Operation, id string, old []byte, deleted bool) {
+	if endpoint.eventStore == nil || endpoint.dryRun {
+		return
+	}
+	event := Event{
+		Op:         op,
+		ID:         id,
+		Prefix:     endpoint.prefix,
+		Timestamp:  time.Now(),
+		OldContent: old,
+	}
+	if !deleted {
+		event.NewContent = endpoint.eventSnapshot(id)
+	}
+	if err := endpoint.eventStore.Append(event); err != nil {
+		log.Errorf("failed to append %v event of %v: %v", op, id, err)
+	}
+}
+
+// NewMemoryEventStore returns an in-memory EventStore which implements EventReader
+func NewMemoryEventStore() EventStore {
+	return &memoryEventStore{}
+}
+
+type memoryEventStore struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (store *memoryEventStore) Append(event Event) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.events = append(store.events, event)
+	return nil
+}
+
+func (store *memoryEventStore) Events(ctx context.Context, prefix, id string) ([]Event, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	var events []Event
+	for _, event := range store.events {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if event.Prefix == prefix && event.ID == id {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,64 @@
+package crud_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventStore", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithEventStore(NewMemoryEventStore())))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should record the mutations of an object", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		code, _ := patch(endpoint, "/key", `{"baz":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = del(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+
+		events, err := endpoint.Replay(context.Background(), "key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(3))
+		Expect(events[0].Op).To(Equal(OperationPut))
+		Expect(events[0].Prefix).To(Equal("test"))
+		Expect(events[0].OldContent).To(BeNil())
+		Expect(events[0].NewContent).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(events[1].Op).To(Equal(OperationPatch))
+		Expect(events[1].OldContent).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(events[1].NewContent).To(MatchJSON(`{"foo":"bar","baz":1}`))
+		Expect(events[2].Op).To(Equal(OperationDelete))
+		Expect(events[2].OldContent).To(MatchJSON(`{"foo":"bar","baz":1}`))
+		Expect(events[2].NewContent).To(BeNil())
+	})
+
+	It("should not record failed writes", func() {
+		code, _ := del(endpoint, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+		events, err := endpoint.Replay(context.Background(), "missing")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(BeEmpty())
+	})
+
+	It("should fail to replay without a readable event store", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpoint("test", store).Replay(context.Background(), "key")
+		Expect(err).To(HaveOccurred())
+	})
+})
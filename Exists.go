@@ -0,0 +1,26 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleExists serves GET /{id}/exists, it responds with 200 {"exists":true} or 404 {"exists":false}
+// without reading the object. Expired objects don't exist.
+// Access rules apply to it like to metadata requests.
+func (endpoint *Endpoint) handleExists(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	exists := endpoint.store.Has(endpoint.objectID(id)) && !endpoint.isExpired(id)
+	status := http.StatusOK
+	if !exists {
+		status = http.StatusNotFound
+	}
+	result := map[string]bool{"exists": exists}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, status, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,56 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exists", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should tell whether an object exists", func() {
+		endpoint := NewEndpoint("test", store)
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		code, data := get(endpoint, "/key/exists")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"exists":true}`))
+		code, data = get(endpoint, "/missing/exists")
+		Expect(code).To(Equal(http.StatusNotFound))
+		Expect(data).To(MatchJSON(`{"exists":false}`))
+	})
+
+	It("should not find expired objects", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithTTL(10*time.Millisecond)))
+		put(endpoint, "/key", `{}`)
+		time.Sleep(20 * time.Millisecond)
+		code, data := get(endpoint, "/key/exists")
+		Expect(code).To(Equal(http.StatusNotFound))
+		Expect(data).To(MatchJSON(`{"exists":false}`))
+	})
+
+	It("should envelope the result", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithResponseEnvelope()))
+		put(endpoint, "/key", `{}`)
+		code, data := get(endpoint, "/key/exists")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"data":{"exists":true},"meta":{}}`))
+	})
+})
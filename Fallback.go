@@ -0,0 +1,49 @@
+package crud
+
+import "net/http"
+
+// WithFallbackToDefault makes GET requests for missing objects respond with 200 and the given content instead of 404,
+// e.g. for CDN origin pulls during a deployment. Lists, metadata and writes are not affected.
+func WithFallbackToDefault(defaultContent []byte) Option {
+	return WithFallbackToDefaultFunc(func(string) []byte {
+		return defaultContent
+	})
+}
+
+// WithFallbackToDefaultFunc is like WithFallbackToDefault but computes the content from the requested id.
+// If fn returns nil the response is 404 as usual.
+func WithFallbackToDefaultFunc(fn func(id string) []byte) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.fallback = fn
+		return nil
+	}
+}
+
+// WithFallbackContentType sets the Content-Type of fallback responses, it defaults to the default content type if set
+func WithFallbackContentType(ct string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.fallbackType = ct
+		return nil
+	}
+}
+
+// writeFallback writes the fallback content of a missing object and returns whether there was one
+func (endpoint *Endpoint) writeFallback(w http.ResponseWriter, id string) bool {
+	if endpoint.fallback == nil {
+		return false
+	}
+	content := endpoint.fallback(id)
+	if content == nil {
+		return false
+	}
+	ct := endpoint.fallbackType
+	if ct == "" {
+		ct = endpoint.defaultContentType
+	}
+	if ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+	return true
+}
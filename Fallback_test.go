@@ -0,0 +1,57 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fallback", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond with the default content for missing objects", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store,
+			WithFallbackToDefault([]byte("<h1>coming soon</h1>")),
+			WithFallbackContentType("text/html"),
+		))
+		resp := request(endpoint, "GET", "/missing", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("text/html"))
+		Expect(resp.Body.String()).To(Equal("<h1>coming soon</h1>"))
+
+		put(endpoint, "/existing", `{"foo":"bar"}`)
+		code, data := get(endpoint, "/existing")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should compute the fallback from the id", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithFallbackToDefaultFunc(func(id string) []byte {
+			if id == "unknown" {
+				return nil
+			}
+			return []byte(`{"id":"` + id + `"}`)
+		})))
+		code, data := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"id":"key"}`))
+		code, _ = get(endpoint, "/unknown")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
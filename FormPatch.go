@@ -0,0 +1,61 @@
+package crud
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// isForm returns whether the request body is declared as url encoded form
+func isForm(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
+
+// decodeFormObject parses a url encoded form into a patch object. Values which are valid json, like numbers
+// and booleans, are decoded, others are kept as strings. Fields given multiple times become arrays.
+// The decoded object is checked against the body limits.
+func (endpoint *Endpoint) decodeFormObject(r *http.Request, obj map[string]interface{}) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	for key, values := range r.PostForm {
+		decoded := make([]interface{}, len(values))
+		for i, value := range values {
+			decoded[i] = decodeFormValue(value)
+		}
+		if len(decoded) == 1 {
+			obj[key] = decoded[0]
+			continue
+		}
+		obj[key] = decoded
+	}
+	encoded, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return endpoint.checkJSONLimits(encoded)
+}
+
+func decodeFormValue(value string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// checkPatchContentType writes 415 and returns false if the strict content type is active and
+// the patch is neither json, yaml, a form nor of the default content type
+func (endpoint *Endpoint) checkPatchContentType(w http.ResponseWriter, r *http.Request) bool {
+	if !endpoint.strictContentType {
+		return true
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || sameMediaType(contentType, "application/json") || isYAML(r) || isForm(r) ||
+		(endpoint.defaultContentType != "" && sameMediaType(contentType, endpoint.defaultContentType)) {
+		return true
+	}
+	endpoint.writeError(w, http.StatusUnsupportedMediaType, "unsupported content type, expected json, yaml or a url encoded form")
+	return false
+}
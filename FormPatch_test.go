@@ -0,0 +1,42 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FormPatch", func() {
+	var endpoint *Endpoint
+	form := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithStrictContentType()))
+		put(endpoint, "/key", `{"name":"old","count":1}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should merge form fields", func() {
+		resp := request(endpoint, "PATCH", "/key", "name=new+name&count=2&active=true&tag=a&tag=b", form)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"name":"new name","count":2,"active":true,"tag":["a","b"]}`))
+	})
+
+	It("should reject other content types", func() {
+		resp := request(endpoint, "PATCH", "/key", "name: new", http.Header{"Content-Type": {"text/plain"}})
+		Expect(resp.Code).To(Equal(http.StatusUnsupportedMediaType))
+		resp = request(endpoint, "PATCH", "/key", `{"name":"new"}`, http.Header{"Content-Type": {"application/json"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,45 @@
+package crud
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithForwardedHeaders trusts the headers set by a reverse proxy: the remote address of requests is taken from
+// the first X-Forwarded-For value or X-Real-IP, the scheme from X-Forwarded-Proto. Handlers, middlewares,
+// loggers and generated urls like the Location header see the rewritten request.
+// Only use this behind a proxy which overwrites these headers, clients can set them freely otherwise.
+func WithForwardedHeaders() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.forwardedHeaders = true
+		return nil
+	}
+}
+
+// forwarded returns a copy of the request with the remote address and scheme given by the proxy headers
+func (endpoint *Endpoint) forwarded(r *http.Request) *http.Request {
+	if !endpoint.forwardedHeaders {
+		return r
+	}
+	addr := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-For"), ",")[0])
+	if addr == "" {
+		addr = strings.TrimSpace(r.Header.Get("X-Real-IP"))
+	}
+	scheme := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]))
+	if scheme != "http" && scheme != "https" {
+		scheme = ""
+	}
+	if addr == "" && scheme == "" {
+		return r
+	}
+	clone := r.WithContext(r.Context())
+	if addr != "" {
+		clone.RemoteAddr = addr
+	}
+	if scheme != "" {
+		u := *r.URL
+		u.Scheme = scheme
+		clone.URL = &u
+	}
+	return clone
+}
@@ -0,0 +1,60 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ForwardedHeaders", func() {
+	var (
+		endpoint   *Endpoint
+		remoteAddr string
+	)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store,
+			WithForwardedHeaders(),
+			WithMiddlewareChain(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					remoteAddr = r.RemoteAddr
+					next.ServeHTTP(w, r)
+				})
+			}),
+		))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should take the remote address from X-Forwarded-For", func() {
+		request(endpoint, "GET", "/", "", http.Header{"X-Forwarded-For": {"10.0.0.1, 10.0.0.2"}, "X-Real-Ip": {"10.0.0.3"}})
+		Expect(remoteAddr).To(Equal("10.0.0.1"))
+	})
+
+	It("should fall back to X-Real-IP", func() {
+		request(endpoint, "GET", "/", "", http.Header{"X-Real-Ip": {"10.0.0.3"}})
+		Expect(remoteAddr).To(Equal("10.0.0.3"))
+	})
+
+	It("should use the forwarded scheme for urls", func() {
+		resp := request(endpoint, "PUT", "/key", `{}`, http.Header{"X-Forwarded-Proto": {"https"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Location")).To(HavePrefix("https://"))
+	})
+
+	It("should ignore the headers by default", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp := request(NewEndpoint("test", store), "PUT", "/key", `{}`, http.Header{"X-Forwarded-Proto": {"https"}})
+		Expect(resp.Header().Get("Location")).To(HavePrefix("http://"))
+	})
+})
@@ -0,0 +1,75 @@
+package crud
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipBodyError is a malformed gzip stream in a request body
+type gzipBodyError struct {
+	err error
+}
+
+func (e *gzipBodyError) Error() string {
+	return "invalid gzip body: " + e.err.Error()
+}
+
+// errDecompressedTooLarge has the message of http.MaxBytesReader errors, so isBodyTooLarge matches it
+var errDecompressedTooLarge = errors.New("http: request body too large")
+
+// gzipBody decompresses a request body and closes the compressed body.
+// If limit is positive reading more than limit decompressed bytes fails.
+type gzipBody struct {
+	*gzip.Reader
+	body  io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (gb *gzipBody) Read(data []byte) (int, error) {
+	n, err := gb.Reader.Read(data)
+	if err != nil && err != io.EOF {
+		err = &gzipBodyError{err}
+	}
+	gb.read += int64(n)
+	if gb.limit > 0 && gb.read > gb.limit {
+		return n, errDecompressedTooLarge
+	}
+	return n, err
+}
+
+func (gb *gzipBody) Close() error {
+	gb.Reader.Close()
+	return gb.body.Close()
+}
+
+// isGzipBody returns whether a request body is gzip compressed
+func isGzipBody(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), encodingGzip)
+}
+
+// decompressBody replaces a body with "Content-Encoding: gzip" by the decompressed body, so the uncompressed
+// bytes are stored. It writes 400 and returns false if the body isn't gzip compressed.
+// WithMaxBodySize limits the decompressed bytes as well, exceeding it fails the write with 413.
+func (endpoint *Endpoint) decompressBody(w http.ResponseWriter, r *http.Request) bool {
+	if !isGzipBody(r) {
+		return true
+	}
+	reader, err := gzip.NewReader(r.Body)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, (&gzipBodyError{err}).Error())
+		return false
+	}
+	r.Body = &gzipBody{Reader: reader, body: r.Body, limit: endpoint.maxBodySize}
+	r.Header.Del("Content-Encoding")
+	return true
+}
+
+// advertiseUploadEncoding tells clients of successful writes that bodies may be gzip compressed
+func advertiseUploadEncoding(w http.ResponseWriter) {
+	w.Header().Set("Accept-Encoding", encodingGzip)
+	w.Header().Set("Content-Encoding", "identity")
+}
@@ -0,0 +1,96 @@
+package crud_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GzipUpload", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	upload := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", "gzip")
+		recorder := httptest.NewRecorder()
+		endpoint.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	compress := func(data string) []byte {
+		buf := new(bytes.Buffer)
+		writer := gzip.NewWriter(buf)
+		writer.Write([]byte(data))
+		writer.Close()
+		return buf.Bytes()
+	}
+
+	It("should store the decompressed body", func() {
+		resp := upload("PUT", "/key", compress(`{"foo":"bar"}`))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Accept-Encoding")).To(Equal("gzip"))
+		Expect(resp.Header().Get("Content-Encoding")).To(Equal("identity"))
+		_, data := get(endpoint, "/key")
+		Expect(data).To(MatchJSON(`{"foo":"bar"}`))
+
+		resp = upload("POST", "/", compress(`{"foo":"baz"}`))
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+	})
+
+	It("should reject malformed gzip bodies", func() {
+		resp := upload("PUT", "/key", []byte(`{"foo":"bar"}`))
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+
+		truncated := compress(`{"foo":"bar","padding":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`)
+		resp = upload("PUT", "/key", truncated[:len(truncated)-6])
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		code, _ := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should keep the stored version if a malformed gzip body is rejected", func() {
+		code, _ := put(endpoint, "/key", `{"v":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		truncated := compress(`{"foo":"bar","padding":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`)
+		resp := upload("PUT", "/key", truncated[:len(truncated)-6])
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		code, data := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"v":1}`))
+		_, data = get(endpoint, "/")
+		Expect(data).To(MatchJSON(`["key"]`))
+	})
+
+	It("should limit the decompressed body with the max body size", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithMaxBodySize(4096)))
+		code, _ := put(endpoint, "/key", `{"v":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		bomb := compress(`{"padding":"` + strings.Repeat("a", 1<<20) + `"}`)
+		Expect(len(bomb)).To(BeNumerically("<", 4096))
+		resp := upload("PUT", "/key", bomb)
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		_, data := get(endpoint, "/key")
+		Expect(data).To(MatchJSON(`{"v":1}`))
+	})
+})
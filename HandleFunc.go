@@ -0,0 +1,37 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleFunc registers a custom route, e.g. HandleFunc("GET", "/search", fn), below the route prefix of the endpoint.
+// Custom routes are matched before the built-in ones, so "/search" isn't shadowed by "/{id}".
+// They are served as is, without the middlewares, auth, logging and metrics of the built-in routes.
+// Registering a method and pattern which is already taken returns an error. Register routes before serving requests.
+func (endpoint *Endpoint) HandleFunc(method, pattern string, fn http.HandlerFunc) error {
+	if _, ok := endpoint.handlers[handlerKey(method, pattern)]; ok {
+		return fmt.Errorf("route %v %v is already registered", method, pattern)
+	}
+	if endpoint.customRouter == nil {
+		endpoint.customRouter = mux.NewRouter()
+	}
+	endpoint.customRouter.Path(endpoint.routePrefix + pattern).Methods(method).HandlerFunc(fn)
+	endpoint.registerHandler(method, pattern, fn)
+	return nil
+}
+
+// serveCustom serves the request if it matches a custom route and returns whether it did
+func (endpoint *Endpoint) serveCustom(w http.ResponseWriter, r *http.Request) bool {
+	if endpoint.customRouter == nil {
+		return false
+	}
+	var match mux.RouteMatch
+	if !endpoint.customRouter.Match(r, &match) {
+		return false
+	}
+	endpoint.customRouter.ServeHTTP(w, r)
+	return true
+}
@@ -0,0 +1,47 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandleFunc", func() {
+	var endpoint *Endpoint
+
+	search := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("results"))
+	}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve custom routes before the built-in ones", func() {
+		Expect(endpoint.HandleFunc("GET", "/search", search)).To(Succeed())
+		code, body := get(endpoint, "/search")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(Equal("results"))
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		_, body = get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(endpoint.Handler("GET", "/search")).NotTo(BeNil())
+	})
+
+	It("should reject routes which are already registered", func() {
+		Expect(endpoint.HandleFunc("GET", "/{id}", search)).NotTo(Succeed())
+		Expect(endpoint.HandleFunc("POST", "/validate", search)).To(Succeed())
+		Expect(endpoint.HandleFunc("POST", "/validate", search)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,35 @@
+package crud
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func handlerKey(method, path string) string {
+	return method + " " + path
+}
+
+// registerHandler remembers a route handler for lookups by Handler().
+// Each handler gets a router with only its own route, which extracts the path variables.
+func (endpoint *Endpoint) registerHandler(method, path string, handler http.Handler) {
+	if endpoint.handlers == nil {
+		endpoint.handlers = make(map[string]http.Handler)
+	}
+	router := mux.NewRouter()
+	router.Path(endpoint.routePrefix + path).Methods(method).Handler(handler)
+	endpoint.handlers[handlerKey(method, path)] = router
+}
+
+// Handler returns the handler registered for a method and route template like "/{id}", or nil if there is none.
+// With WithCustomIDPath the template contains the custom param instead of id.
+// The handler includes the configured middlewares, logging and metrics but skips dispatching over all routes.
+// The path of requests passed to it still has to match the route template including the route prefix,
+// paths which don't match get 404.
+func (endpoint *Endpoint) Handler(method, path string) http.HandlerFunc {
+	handler, ok := endpoint.handlers[handlerKey(method, path)]
+	if !ok {
+		return nil
+	}
+	return handler.ServeHTTP
+}
@@ -0,0 +1,51 @@
+package crud_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should return the handler of a route", func() {
+		put(endpoint, "/key", `{"a":1}`)
+		handler := endpoint.Handler("PATCH", "/{id}")
+		Expect(handler).NotTo(BeNil())
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/key", bytes.NewBufferString(`{"b":2}`))
+		handler(recorder, req)
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should only serve its own route", func() {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		endpoint.Handler("GET", "/{id}")(recorder, req)
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return nil for unknown routes", func() {
+		Expect(endpoint.Handler("PUT", "/")).To(BeNil())
+		Expect(endpoint.Handler("GET", "/unknown")).To(BeNil())
+	})
+})
@@ -0,0 +1,66 @@
+package crud
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/trusch/streamstore"
+)
+
+// NewEndpointWithHierarchicalStorage constructs the endpoints of a nested resource like projects/{pid}/tasks/{tid}
+// sharing one store. The child objects are stored below <parentPrefix>::<childPrefix>, POST to the child requires
+// ?parent_id=<pid> of an existing parent object and creates the id <pid>::<uuid>.
+// The parent doesn't list the child objects although they share its key space.
+func NewEndpointWithHierarchicalStorage(store streamstore.Storage, parentPrefix, childPrefix string) (parent, child http.Handler) {
+	// without custom delimiter construction can't fail
+	parentEndpoint, _ := NewEndpointWithOptions(parentPrefix, store, withChildSpace(childPrefix))
+	childEndpoint, _ := NewEndpointWithOptions(childPrefix, store,
+		WithStorePrefix(parentPrefix+defaultDelimiter+childPrefix),
+		withHierarchyParent(parentEndpoint),
+	)
+	return parentEndpoint, childEndpoint
+}
+
+// withChildSpace hides the keys of a nested child endpoint from this endpoint
+func withChildSpace(childPrefix string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.childSpaces = append(endpoint.childSpaces, childPrefix)
+		return nil
+	}
+}
+
+// withHierarchyParent namespaces the ids created by POST below an object of the parent
+func withHierarchyParent(parent *Endpoint) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.hierarchyParent = parent
+		return nil
+	}
+}
+
+// inChildSpace returns whether the id belongs to a nested child endpoint
+func (endpoint *Endpoint) inChildSpace(id string) bool {
+	for _, space := range endpoint.childSpaces {
+		if strings.HasPrefix(id, space+endpoint.delimiter) {
+			return true
+		}
+	}
+	return false
+}
+
+// postID returns the id for a new object. For nested endpoints it is namespaced below the parent_id, if that
+// is missing or invalid 400 is returned, if the parent doesn't exist 422, and false in both cases.
+func (endpoint *Endpoint) postID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if endpoint.hierarchyParent == nil {
+		return endpoint.newID(), true
+	}
+	parentID := r.URL.Query().Get("parent_id")
+	if err := validateID(parentID, endpoint.delimiter); err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, "invalid parent_id: "+err.Error())
+		return "", false
+	}
+	if !endpoint.hierarchyParent.store.Has(endpoint.hierarchyParent.objectID(parentID)) {
+		endpoint.writeError(w, http.StatusUnprocessableEntity, "parent object not found")
+		return "", false
+	}
+	return parentID + endpoint.delimiter + endpoint.newID(), true
+}
@@ -0,0 +1,59 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hierarchy", func() {
+	var (
+		store         streamstore.Storage
+		parent, child http.Handler
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		parent, child = NewEndpointWithHierarchicalStorage(store, "projects", "tasks")
+		put(parent, "/p1", `{"name":"project"}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should namespace posted children below their parent", func() {
+		code, data := post(child, "/?parent_id=p1", `{"name":"task"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		resp := struct{ ID string }{}
+		Expect(json.Unmarshal([]byte(data), &resp)).To(Succeed())
+		Expect(resp.ID).To(HavePrefix("p1::"))
+		Expect(store.Has("projects::tasks::" + resp.ID)).To(BeTrue())
+
+		code, data = get(child, "/"+resp.ID)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"name":"task"}`))
+		_, data = get(child, "/")
+		Expect(data).To(MatchJSON(`["` + resp.ID + `"]`))
+		_, data = get(parent, "/")
+		Expect(data).To(MatchJSON(`["p1"]`))
+	})
+
+	It("should require an existing parent", func() {
+		code, _ := post(child, "/", `{}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = post(child, "/?parent_id=missing", `{}`)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+		_, data := get(child, "/")
+		Expect(data).To(MatchJSON(`[]`))
+	})
+})
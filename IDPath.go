@@ -0,0 +1,33 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultIDParam = "id"
+
+// WithCustomIDPath names the route variable of the object id param instead of "id", so routes are registered
+// as "/{param}". Use it if the endpoint is mounted on a router which already uses the variable "id".
+func WithCustomIDPath(param string) Option {
+	return func(endpoint *Endpoint) error {
+		if param == "" || param == "tag" || strings.ContainsAny(param, "{}/:") {
+			return fmt.Errorf("invalid id param %q", param)
+		}
+		endpoint.idParam = param
+		return nil
+	}
+}
+
+// requestID returns the object id of the request
+func (endpoint *Endpoint) requestID(r *http.Request) string {
+	return mux.Vars(r)[endpoint.idParam]
+}
+
+// routeTemplate replaces the id variable of a route template with the configured param
+func (endpoint *Endpoint) routeTemplate(path string) string {
+	return strings.Replace(path, "{"+defaultIDParam+"}", "{"+endpoint.idParam+"}", 1)
+}
@@ -0,0 +1,52 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDPath", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithCustomIDPath("item")))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should read the id from the custom route variable", func() {
+		code, _ := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, body := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(endpoint.Handler("GET", "/{item}")).NotTo(BeNil())
+	})
+
+	It("should work below a router using the id variable", func() {
+		router := mux.NewRouter()
+		router.PathPrefix("/users/{id}/items").Handler(http.StripPrefix("/users/alice/items", endpoint))
+		code, _ := put(router, "/users/alice/items/key", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid params", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithCustomIDPath(""))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", nil, WithCustomIDPath("tag"))
+		Expect(err).To(HaveOccurred())
+	})
+})
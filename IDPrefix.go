@@ -0,0 +1,46 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// WithObjectIDPrefix makes POST generate ids like "user_<uuid>" for the prefix "user_".
+// Lists return the ids without the prefix unless WithIDPrefixInListResponse is given,
+// all other requests use the full id. Fails if the prefixed ids wouldn't pass ValidateID.
+func WithObjectIDPrefix(pfx string) Option {
+	return func(endpoint *Endpoint) error {
+		if pfx == "" {
+			return errors.New("id prefix must not be empty")
+		}
+		if err := ValidateID(pfx + uuid.NewV4().String()); err != nil {
+			return fmt.Errorf("invalid id prefix %q: %v", pfx, err)
+		}
+		endpoint.idPrefix = pfx
+		return nil
+	}
+}
+
+// WithIDPrefixInListResponse keeps the prefix of WithObjectIDPrefix in list responses
+func WithIDPrefixInListResponse() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.listIDPrefix = true
+		return nil
+	}
+}
+
+// newID returns a new random object id
+func (endpoint *Endpoint) newID() string {
+	return endpoint.idPrefix + uuid.NewV4().String()
+}
+
+// listedID returns the id as it is shown in list responses
+func (endpoint *Endpoint) listedID(id string) string {
+	if endpoint.listIDPrefix {
+		return id
+	}
+	return strings.TrimPrefix(id, endpoint.idPrefix)
+}
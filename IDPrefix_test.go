@@ -0,0 +1,61 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDPrefix", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	create := func(opts ...Option) (*Endpoint, string) {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, append(opts, WithObjectIDPrefix("user_"))...))
+		code, data := post(endpoint, "/", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		result := map[string]string{}
+		Expect(json.Unmarshal([]byte(data), &result)).To(Succeed())
+		return endpoint, result["id"]
+	}
+
+	list := func(endpoint *Endpoint) []string {
+		_, data := get(endpoint, "/")
+		ids := []string{}
+		Expect(json.Unmarshal([]byte(data), &ids)).To(Succeed())
+		return ids
+	}
+
+	It("should prefix generated ids", func() {
+		endpoint, id := create()
+		Expect(id).To(HavePrefix("user_"))
+		code, body := get(endpoint, "/"+id)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(list(endpoint)).To(Equal([]string{strings.TrimPrefix(id, "user_")}))
+	})
+
+	It("should keep the prefix in lists on request", func() {
+		endpoint, id := create(WithIDPrefixInListResponse())
+		Expect(list(endpoint)).To(Equal([]string{id}))
+	})
+
+	It("should reject invalid prefixes", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithObjectIDPrefix("a/"))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithObjectIDPrefix(""))
+		Expect(err).To(HaveOccurred())
+	})
+})
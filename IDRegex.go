@@ -0,0 +1,51 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// WithObjectIDRegex enforces a naming convention like `\d{4}-\d{2}-\d{2}-[a-z-]+` for the ids clients address:
+// requests on single objects and multi gets get 400 if an id doesn't match the whole pattern, imports skip such ids.
+// This applies on top of ValidateID, which checks the ids first. Ids generated by POST aren't checked.
+// Fails if pattern is invalid.
+func WithObjectIDRegex(pattern string) Option {
+	return func(endpoint *Endpoint) error {
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("invalid object id regex %q: %v", pattern, err)
+		}
+		endpoint.idRegex = re
+		return nil
+	}
+}
+
+// matchesIDRegex returns whether the id follows the naming convention
+func (endpoint *Endpoint) matchesIDRegex(id string) bool {
+	return endpoint.idRegex == nil || endpoint.idRegex.MatchString(id)
+}
+
+// withIDRegex wraps the handlers of operations on single objects with the naming convention check
+func (endpoint *Endpoint) withIDRegex(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if endpoint.idRegex == nil {
+		return fn
+	}
+	switch op {
+	case OperationList, OperationQuery, OperationPost, OperationPatchAll, OperationDeleteAll, OperationWatch, OperationStats, OperationImport:
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids := []string{endpoint.requestID(r)}
+		if op == OperationMove {
+			ids = append(ids, r.URL.Query().Get("to"))
+		}
+		for _, id := range ids {
+			if !endpoint.matchesIDRegex(id) {
+				endpoint.writeError(w, http.StatusBadRequest, "id "+id+" doesn't match the naming convention")
+				return
+			}
+		}
+		fn(w, r)
+	}
+}
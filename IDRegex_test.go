@@ -0,0 +1,77 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IDRegex", func() {
+	var (
+		store    streamstore.Storage
+		endpoint *Endpoint
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectIDRegex(`\d{4}-\d{2}-\d{2}-[a-z-]+`)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should accept ids following the convention", func() {
+		code, _ := put(endpoint, "/2026-10-14-release-notes", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(endpoint, "/2026-10-14-release-notes")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = del(endpoint, "/2026-10-14-release-notes")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject other ids with 400", func() {
+		code, _ := put(endpoint, "/release-notes", `{}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = put(endpoint, "/x2026-10-14-release-notes", `{}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		Expect(store.Has("test::release-notes")).To(BeFalse())
+		code, _ = get(endpoint, "/release-notes")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = patch(endpoint, "/release-notes", `{}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = del(endpoint, "/release-notes")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should reject other ids in multi gets", func() {
+		put(NewEndpoint("test", store), "/release-notes", `{}`)
+		put(endpoint, "/2026-10-14-release-notes", `{}`)
+		code, _ := get(endpoint, "/?ids=2026-10-14-release-notes,release-notes")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, body := get(endpoint, "/?ids=2026-10-14-release-notes")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"2026-10-14-release-notes":{}}`))
+	})
+
+	It("should validate ids before matching the pattern", func() {
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectIDRegex(`.+`)))
+		put(endpoint, "/key", `{}`)
+		resp := request(endpoint, "PUT", "/key::tags", `["x"]`, nil)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(ContainSubstring("reserved sidecar name"))
+	})
+
+	It("should fail with an invalid pattern", func() {
+		_, err := NewEndpointWithOptions("test", store, WithObjectIDRegex(`[a-z`))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// WithIPAllowList only accepts requests from client ips within one of the given cidrs like "10.0.0.0/8",
+// all others get 403. With WithForwardedHeaders the client ip is taken from X-Forwarded-For.
+// Fails if a cidr is invalid.
+func WithIPAllowList(cidrs []string) Option {
+	return func(endpoint *Endpoint) error {
+		nets, err := parseCIDRs(cidrs)
+		if err != nil {
+			return err
+		}
+		endpoint.ipAllowList = append(endpoint.ipAllowList, nets...)
+		return nil
+	}
+}
+
+// WithIPDenyList rejects requests from client ips within one of the given cidrs with 403 and accepts all others.
+// The deny list takes precedence over WithIPAllowList. Fails if a cidr is invalid.
+func WithIPDenyList(cidrs []string) Option {
+	return func(endpoint *Endpoint) error {
+		nets, err := parseCIDRs(cidrs)
+		if err != nil {
+			return err
+		}
+		endpoint.ipDenyList = append(endpoint.ipDenyList, nets...)
+		return nil
+	}
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %v", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// clientIP returns the ip of the remote address, which may lack the port if it was set from proxy headers
+func clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkClientIP writes 403 and returns false if the client ip is denied or not allowed.
+// Requests without a parseable client ip are rejected as soon as a list is configured.
+func (endpoint *Endpoint) checkClientIP(w http.ResponseWriter, r *http.Request) bool {
+	if len(endpoint.ipAllowList) == 0 && len(endpoint.ipDenyList) == 0 {
+		return true
+	}
+	ip := clientIP(r)
+	if ip == nil || containsIP(endpoint.ipDenyList, ip) || (len(endpoint.ipAllowList) > 0 && !containsIP(endpoint.ipAllowList, ip)) {
+		endpoint.writeError(w, http.StatusForbidden, "access denied")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package crud_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPFilter", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	getFrom := func(handler http.Handler, remoteAddr string, header http.Header) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	It("should only allow the listed ranges", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithIPAllowList([]string{"10.0.0.0/8", "fd00::/8"})))
+		Expect(getFrom(endpoint, "10.1.2.3:4321", nil)).To(Equal(http.StatusOK))
+		Expect(getFrom(endpoint, "[fd12::1]:4321", nil)).To(Equal(http.StatusOK))
+		Expect(getFrom(endpoint, "192.168.1.1:4321", nil)).To(Equal(http.StatusForbidden))
+	})
+
+	It("should block the denied ranges", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithIPDenyList([]string{"192.168.0.0/16"})))
+		Expect(getFrom(endpoint, "10.1.2.3:4321", nil)).To(Equal(http.StatusOK))
+		Expect(getFrom(endpoint, "192.168.1.1:4321", nil)).To(Equal(http.StatusForbidden))
+	})
+
+	It("should use the forwarded client ip", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithIPAllowList([]string{"10.0.0.0/8"}), WithForwardedHeaders()))
+		Expect(getFrom(endpoint, "127.0.0.1:4321", http.Header{"X-Forwarded-For": {"10.1.2.3, 127.0.0.1"}})).To(Equal(http.StatusOK))
+		Expect(getFrom(endpoint, "10.1.2.3:4321", http.Header{"X-Forwarded-For": {"8.8.8.8"}})).To(Equal(http.StatusForbidden))
+
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithIPAllowList([]string{"10.0.0.0/8"})))
+		Expect(getFrom(endpoint, "10.1.2.3:4321", http.Header{"X-Forwarded-For": {"8.8.8.8"}})).To(Equal(http.StatusOK))
+	})
+
+	It("should fail with invalid cidrs", func() {
+		_, err := NewEndpointWithOptions("test", store, WithIPAllowList([]string{"10.0.0.0"}))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithIPDenyList([]string{"nope"}))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,264 @@
+package crud
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// maxDefaultImportConcurrency caps the default number of import workers
+const maxDefaultImportConcurrency = 8
+
+// WithBatchImportConcurrency writes up to n objects of an import simultaneously.
+// The default is the number of CPUs, but at most 8.
+func WithBatchImportConcurrency(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return fmt.Errorf("import concurrency must be at least 1, got %v", n)
+		}
+		endpoint.importConcurrency = n
+		return nil
+	}
+}
+
+func defaultImportConcurrency() int {
+	if n := runtime.NumCPU(); n < maxDefaultImportConcurrency {
+		return n
+	}
+	return maxDefaultImportConcurrency
+}
+
+// importResult is the outcome of importing one archive entry
+type importResult struct {
+	File   string `json:"file"`
+	ID     string `json:"id,omitempty"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleImport stores every file of a zip archive as object with the file name minus extension as id.
+// Each file passes the same checks as a PUT of its id, the content type is derived from the file extension.
+// It responds with 207 and {"results":[...],"errors":[...]} listing the outcome per file.
+// Since zip archives aren't streamable the archive is held in memory, limit it with WithMaxBodySize.
+// The limit applies to the decompressed size of each file as well.
+// The route takes precedence over PUT /{id}, so objects with the id "import" can't be written with PUT.
+func (endpoint *Endpoint) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil {
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
+		return
+	}
+	if !sameMediaType(r.Header.Get("Content-Type"), "application/zip") {
+		endpoint.writeError(w, http.StatusUnsupportedMediaType, "unsupported content type, expected application/zip")
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, "invalid zip archive: "+err.Error())
+		return
+	}
+	var files []*zip.File
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, "/") {
+			files = append(files, file)
+		}
+	}
+	capacity, err := endpoint.remainingCapacity()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results := make([]importResult, 0, len(files))
+	errors := make([]importResult, 0)
+	for _, result := range endpoint.importFiles(r, files, capacity) {
+		if result.Error != "" {
+			errors = append(errors, result)
+			continue
+		}
+		results = append(results, result)
+	}
+	result := map[string]interface{}{
+		"results": results,
+		"errors":  errors,
+	}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusMultiStatus, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(result)
+}
+
+// importFiles imports the files with the configured number of workers, the results are in the order of the files
+func (endpoint *Endpoint) importFiles(r *http.Request, files []*zip.File, capacity *objectCapacity) []importResult {
+	results := make([]importResult, len(files))
+	indexes := make(chan int, len(files))
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for worker := 0; worker < endpoint.importConcurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = endpoint.importFile(r, files[i], capacity)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// importFile stores one file of an archive like a PUT of its id would, with the same checks.
+// New objects take room from capacity, if the object count is limited.
+func (endpoint *Endpoint) importFile(r *http.Request, file *zip.File, capacity *objectCapacity) importResult {
+	id := strings.TrimSuffix(file.Name, path.Ext(file.Name))
+	result := importResult{File: file.Name, ID: id}
+	fail := func(status int, msg string) importResult {
+		result.Status = status
+		result.Error = msg
+		return result
+	}
+	if err := validateID(id, endpoint.delimiter); err != nil {
+		result.ID = ""
+		return fail(http.StatusBadRequest, err.Error())
+	}
+	if !endpoint.allowedID(id) {
+		return fail(http.StatusForbidden, "id doesn't start with an allowed prefix")
+	}
+	if !endpoint.matchesIDRegex(id) {
+		return fail(http.StatusBadRequest, "id doesn't match the naming convention")
+	}
+	if !endpoint.mayAccess(r, OperationPut, id) || !endpoint.authorized(r, OperationPut, id) {
+		return fail(http.StatusForbidden, "access denied")
+	}
+	// the size in the archive headers can be forged, the limits are enforced while reading as well
+	if endpoint.objectSizeLimit > 0 && file.UncompressedSize64 > uint64(endpoint.objectSizeLimit) {
+		return fail(http.StatusRequestEntityTooLarge, "object too large")
+	}
+	if endpoint.maxBodySize > 0 && file.UncompressedSize64 > uint64(endpoint.maxBodySize) {
+		return fail(http.StatusRequestEntityTooLarge, errDecompressedTooLarge.Error())
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return fail(http.StatusBadRequest, err.Error())
+	}
+	defer reader.Close()
+	req, err := http.NewRequest("PUT", r.URL.String(), &archiveFileReader{Reader: reader, limit: endpoint.maxBodySize})
+	if err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+	req = req.WithContext(r.Context())
+	if contentType := mime.TypeByExtension(path.Ext(file.Name)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	created := capacity != nil && !endpoint.store.Has(endpoint.objectID(id))
+	if created && !capacity.take() {
+		return fail(http.StatusInsufficientStorage, fmt.Sprintf("object count limit of %v reached", endpoint.objectCountLimit))
+	}
+	old := endpoint.eventSnapshot(id)
+	response := newImportResponse()
+	saved := false
+	if endpoint.conflictDetection {
+		// archive entries can't carry an If-Match, so they may only create objects
+		req.Header.Set("If-None-Match", "*")
+		endpoint.casMutex.Lock()
+		saved = endpoint.checkVersion(response, req, id) && endpoint.saveObject(response, req, OperationPut, id)
+		endpoint.casMutex.Unlock()
+	} else {
+		saved = endpoint.saveObject(response, req, OperationPut, id)
+	}
+	if !saved {
+		if created {
+			capacity.release()
+		}
+		return fail(response.status, response.message())
+	}
+	endpoint.notify(OperationImport, id)
+	endpoint.appendEvent(OperationImport, id, old, false)
+	result.Status = http.StatusOK
+	return result
+}
+
+// archiveFileError is a failure reading the content of an archive entry, e.g. a checksum mismatch
+type archiveFileError struct {
+	err error
+}
+
+func (e *archiveFileError) Error() string {
+	return "invalid archive entry: " + e.err.Error()
+}
+
+// archiveFileReader marks read errors of an archive entry as archiveFileError.
+// If limit is positive reading more than limit decompressed bytes fails, like for gzip bodies.
+type archiveFileReader struct {
+	io.Reader
+	limit int64
+	read  int64
+}
+
+func (ar *archiveFileReader) Read(data []byte) (int, error) {
+	n, err := ar.Reader.Read(data)
+	if err != nil && err != io.EOF {
+		err = &archiveFileError{err}
+	}
+	ar.read += int64(n)
+	if ar.limit > 0 && ar.read > ar.limit {
+		return n, errDecompressedTooLarge
+	}
+	return n, err
+}
+
+// importResponse collects the error response saveObject writes for a rejected file
+type importResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newImportResponse() *importResponse {
+	return &importResponse{header: make(http.Header)}
+}
+
+func (ir *importResponse) Header() http.Header {
+	return ir.header
+}
+
+func (ir *importResponse) WriteHeader(status int) {
+	if ir.status == 0 {
+		ir.status = status
+	}
+}
+
+func (ir *importResponse) Write(data []byte) (int, error) {
+	return ir.body.Write(data)
+}
+
+// message returns the error message of the response, unwrapping an enveloped error
+func (ir *importResponse) message() string {
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(ir.body.Bytes(), &envelope) == nil && envelope.Error.Message != "" {
+		return envelope.Error.Message
+	}
+	return ir.body.String()
+}
@@ -0,0 +1,215 @@
+package crud_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func zipArchive(files map[string]string) string {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for name, content := range files {
+		writer, err := archive.Create(name)
+		Expect(err).NotTo(HaveOccurred())
+		writer.Write([]byte(content))
+	}
+	Expect(archive.Close()).To(Succeed())
+	return buf.String()
+}
+
+// slowWriteStore takes a while to open writers
+type slowWriteStore struct {
+	streamstore.Storage
+}
+
+func (store slowWriteStore) GetWriter(id string) (io.WriteCloser, error) {
+	time.Sleep(2 * time.Millisecond)
+	return store.Storage.GetWriter(id)
+}
+
+var _ = Describe("Import", func() {
+	var handler http.Handler
+	zipHeader := http.Header{"Content-Type": {"application/zip"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = NewEndpoint("test", store)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should store each file as object", func() {
+		resp := request(handler, "PUT", "/import", zipArchive(map[string]string{
+			"first.json":  `{"a":1}`,
+			"second.json": `{"b":2}`,
+			"dir/bad.txt": "foobar",
+		}), zipHeader)
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		var result struct {
+			Results []struct {
+				ID     string `json:"id"`
+				Status int    `json:"status"`
+			} `json:"results"`
+			Errors []struct {
+				File string `json:"file"`
+			} `json:"errors"`
+		}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+		Expect(result.Results).To(HaveLen(2))
+		Expect(result.Errors).To(HaveLen(1))
+		Expect(result.Errors[0].File).To(Equal("dir/bad.txt"))
+		_, data := get(handler, "/first")
+		Expect(data).To(MatchJSON(`{"a":1}`))
+		_, data = get(handler, "/second")
+		Expect(data).To(MatchJSON(`{"b":2}`))
+	})
+
+	It("should reject other content types and broken archives", func() {
+		Expect(request(handler, "PUT", "/import", "foobar", nil).Code).To(Equal(http.StatusUnsupportedMediaType))
+		Expect(request(handler, "PUT", "/import", "foobar", zipHeader).Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should respect the max body size", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithMaxBodySize(16)))
+		resp := request(handler, "PUT", "/import", zipArchive(map[string]string{"first.json": `{"a":1}`}), zipHeader)
+		Expect(resp.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		code, _ := put(handler, "/key", "0123456789abcdefg")
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		code, _ = get(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should write objects concurrently and keep the order of the results", func() {
+		files := make(map[string]string)
+		for i := 0; i < 100; i++ {
+			files["object"+strconv.Itoa(i)+".json"] = "{}"
+		}
+		archive := zipArchive(files)
+		importWith := func(n int) (time.Duration, []interface{}) {
+			store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+			Expect(err).NotTo(HaveOccurred())
+			endpoint := mustEndpoint(NewEndpointWithOptions("test", slowWriteStore{store}, WithBatchImportConcurrency(n)))
+			start := time.Now()
+			resp := request(endpoint, "PUT", "/import", archive, zipHeader)
+			duration := time.Since(start)
+			Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+			var result map[string][]interface{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+			Expect(result["results"]).To(HaveLen(100))
+			return duration, result["results"]
+		}
+		sequential, sequentialResults := importWith(1)
+		concurrent, concurrentResults := importWith(10)
+		Expect(concurrent).To(BeNumerically("<", sequential))
+		Expect(concurrentResults).To(Equal(sequentialResults))
+	})
+
+	It("should check each file like a put of its id", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithRequestBodyTransform(func(op Operation, id string, body []byte) ([]byte, error) {
+			if id == "rejected" {
+				return nil, TransformError{ClientError: true, Err: errors.New("rejected")}
+			}
+			return body, nil
+		})))
+		resp := request(handler, "PUT", "/import", zipArchive(map[string]string{
+			"accepted.json": `{"a":1}`,
+			"rejected.json": `{"b":2}`,
+		}), zipHeader)
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		Expect(resp.Body.String()).To(MatchJSON(`{
+			"results": [{"file":"accepted.json","id":"accepted","status":200}],
+			"errors": [{"file":"rejected.json","id":"rejected","status":400,"error":"rejected"}]
+		}`))
+		code, _ := get(handler, "/rejected")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should keep the stored version if a file with a forged size is rejected", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectSizeLimit(16)))
+		put(handler, "/key", `{"v":1}`)
+		content := []byte(strings.Repeat("x", 1024))
+		buf := new(bytes.Buffer)
+		archive := zip.NewWriter(buf)
+		writer, err := archive.CreateRaw(&zip.FileHeader{
+			Name:               "key.json",
+			Method:             zip.Store,
+			CRC32:              crc32.ChecksumIEEE(content),
+			CompressedSize64:   uint64(len(content)),
+			UncompressedSize64: 8,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		writer.Write(content)
+		Expect(archive.Close()).To(Succeed())
+		resp := request(handler, "PUT", "/import", buf.String(), zipHeader)
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		Expect(resp.Body.String()).To(ContainSubstring(`"status":400`))
+		_, data := get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"v":1}`))
+	})
+
+	It("should limit the decompressed size of each file", func() {
+		store, _ := uriparser.NewFromURI("file:///tmp/test", nil)
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithMaxBodySize(4096)))
+		put(handler, "/forged", `{"v":1}`)
+		content := []byte(strings.Repeat("a", 1<<20))
+		compressed := new(bytes.Buffer)
+		compressor, _ := flate.NewWriter(compressed, flate.BestCompression)
+		compressor.Write(content)
+		compressor.Close()
+		buf := new(bytes.Buffer)
+		archive := zip.NewWriter(buf)
+		for _, name := range []string{"bomb.txt", "forged.txt"} {
+			header := &zip.FileHeader{
+				Name:               name,
+				Method:             zip.Deflate,
+				CRC32:              crc32.ChecksumIEEE(content),
+				CompressedSize64:   uint64(compressed.Len()),
+				UncompressedSize64: uint64(len(content)),
+			}
+			if name == "forged.txt" {
+				header.UncompressedSize64 = 8
+			}
+			writer, err := archive.CreateRaw(header)
+			Expect(err).NotTo(HaveOccurred())
+			writer.Write(compressed.Bytes())
+		}
+		Expect(archive.Close()).To(Succeed())
+		Expect(buf.Len()).To(BeNumerically("<", 4096))
+		resp := request(handler, "PUT", "/import", buf.String(), zipHeader)
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		Expect(resp.Body.String()).To(ContainSubstring(`{"file":"bomb.txt","id":"bomb","status":413,"error":"http: request body too large"}`))
+		Expect(resp.Body.String()).To(ContainSubstring(`{"file":"forged.txt","id":"forged","status":400`))
+		code, _ := get(handler, "/bomb")
+		Expect(code).To(Equal(http.StatusNotFound))
+		_, data := get(handler, "/forged")
+		Expect(data).To(MatchJSON(`{"v":1}`))
+	})
+
+	It("should reject invalid concurrency", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithBatchImportConcurrency(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
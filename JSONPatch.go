@@ -0,0 +1,249 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPatchOperation is one operation of a RFC 6902 JSON Patch
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch is a parsed RFC 6902 JSON Patch
+type jsonPatch []jsonPatchOperation
+
+// parseJSONPatch decodes and checks a JSON Patch document
+func parseJSONPatch(data []byte) (jsonPatch, error) {
+	var patch jsonPatch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, &unmarshalError{err: err}
+	}
+	for i, op := range patch {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %v (%v) needs a value", i, op.Op)
+			}
+		case "remove":
+		case "move", "copy":
+			if _, err := parseJSONPointer(op.From); err != nil {
+				return nil, fmt.Errorf("operation %v (%v): %v", i, op.Op, err)
+			}
+		default:
+			return nil, fmt.Errorf("operation %v has the unknown op %q", i, op.Op)
+		}
+		if _, err := parseJSONPointer(op.Path); err != nil {
+			return nil, fmt.Errorf("operation %v (%v): %v", i, op.Op, err)
+		}
+	}
+	return patch, nil
+}
+
+// apply applies all operations to doc and returns the patched document.
+// doc may be modified even if an operation fails.
+func (patch jsonPatch) apply(doc interface{}) (interface{}, error) {
+	for i, op := range patch {
+		var err error
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, fmt.Errorf("operation %v (%v %v): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func (op jsonPatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, _ := parseJSONPointer(op.Path)
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, decodeValue(op.Value))
+	case "remove":
+		return pointerRemove(doc, path)
+	case "replace":
+		if _, err := pointerGet(doc, path); err != nil {
+			return nil, err
+		}
+		doc, _ = pointerRemove(doc, path)
+		return pointerAdd(doc, path, decodeValue(op.Value))
+	case "move":
+		from, _ := parseJSONPointer(op.From)
+		if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+			return nil, errors.New("can't move a value into itself")
+		}
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = pointerRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "copy":
+		from, _ := parseJSONPointer(op.From)
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, deepCopy(value))
+	case "test":
+		value, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, decodeValue(op.Value)) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// decodeValue decodes a fresh copy of a patch value, so documents never share values
+func decodeValue(raw json.RawMessage) interface{} {
+	var value interface{}
+	json.Unmarshal(raw, &value)
+	return value
+}
+
+func deepCopy(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	return decodeValue(data)
+}
+
+// parseJSONPointer splits a RFC 6901 JSON Pointer into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token, allowing index == length if appending is allowed
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %v out of range", index)
+	}
+	return index, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := doc.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", token)
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("can't resolve %q in a scalar value", token)
+		}
+	}
+	return doc, nil
+}
+
+// pointerUpdate replaces the parent container of the last token by the result of fn
+func pointerUpdate(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		child, ok := container[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", path[0])
+		}
+		child, err := pointerUpdate(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[path[0]] = child
+		return container, nil
+	case []interface{}:
+		index, err := arrayIndex(path[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerUpdate(container[index], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = child
+		return container, nil
+	}
+	return nil, fmt.Errorf("can't resolve %q in a scalar value", path[0])
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+			container = append(container, nil)
+			copy(container[index+1:], container[index:])
+			container[index] = value
+			return container, nil
+		}
+		return nil, fmt.Errorf("can't add %q to a scalar value", token)
+	})
+}
+
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("can't remove the whole document")
+	}
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("field %q not found", token)
+			}
+			delete(container, token)
+			return container, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(container[:index], container[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("can't remove %q from a scalar value", token)
+	})
+}
@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WithListFilter only lists the objects for which fn returns true, this applies to GET / including ?changed_since=
+// and ?ids=, and to QUERY /. Every object is read for each list request, so this is only suitable for moderate
+// numbers of objects. Objects which can't be read are left out.
+func WithListFilter(fn func(id string, content []byte) bool) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.listFilter = fn
+		return nil
+	}
+}
+
+// WithListFilterConcurrency reads up to n objects concurrently for WithListFilter, the default is 1
+func WithListFilterConcurrency(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return fmt.Errorf("invalid list filter concurrency %v", n)
+		}
+		endpoint.filterConcurrency = n
+		return nil
+	}
+}
+
+// filterByFunc applies the list filter, keeping the order of the ids
+func (endpoint *Endpoint) filterByFunc(ids []string) []string {
+	if endpoint.listFilter == nil {
+		return ids
+	}
+	keep := make([]bool, len(ids))
+	indexes := make(chan int, len(ids))
+	for i := range ids {
+		indexes <- i
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for worker := 0; worker < endpoint.filterConcurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				content, err := endpoint.readContent(endpoint.objectID(ids[i]))
+				keep[i] = err == nil && endpoint.listFilter(ids[i], content)
+			}
+		}()
+	}
+	wg.Wait()
+	result := ids[:0]
+	for i, id := range ids {
+		if keep[i] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
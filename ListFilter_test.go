@@ -0,0 +1,70 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListFilter", func() {
+	active := func(id string, content []byte) bool {
+		obj := struct{ Active bool }{}
+		return json.Unmarshal(content, &obj) == nil && obj.Active
+	}
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	for _, concurrency := range []int{1, 4} {
+		concurrency := concurrency
+		It(fmt.Sprintf("should only list matching objects with concurrency %v", concurrency), func() {
+			store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+			Expect(err).NotTo(HaveOccurred())
+			endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithListFilter(active), WithListFilterConcurrency(concurrency), WithSortedList(Ascending)))
+			for i := 0; i < 10; i++ {
+				put(endpoint, fmt.Sprintf("/key%v", i), fmt.Sprintf(`{"active":%v}`, i%3 == 0))
+			}
+			code, data := get(endpoint, "/")
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(data).To(MatchJSON(`["key0","key3","key6","key9"]`))
+		})
+	}
+
+	It("should filter every list-like request", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithListFilter(active), WithMetadata()))
+		put(endpoint, "/visible", `{"active":true}`)
+		put(endpoint, "/hidden", `{"active":false}`)
+
+		code, data := get(endpoint, "/?changed_since=2000-01-01T00:00:00Z")
+		Expect(code).To(Equal(http.StatusOK))
+		var entries []struct{ ID string }
+		Expect(json.Unmarshal([]byte(data), &entries)).To(Succeed())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ID).To(Equal("visible"))
+
+		resp := request(endpoint, "QUERY", "/", `{}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`["visible"]`))
+
+		code, data = get(endpoint, "/?ids=visible,hidden")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"visible":{"active":true},"hidden":null}`))
+	})
+
+	It("should reject an invalid concurrency", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithListFilterConcurrency(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
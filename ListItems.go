@@ -0,0 +1,52 @@
+package crud
+
+import "net/http"
+
+// WithCustomListResponse replaces the json array of ids in list and query responses by the json encoding
+// of the value fn returns for the listed ids, e.g. to add links or metadata per item.
+// The ids are passed as listed, without an object id prefix unless WithIDPrefixInListResponse is set.
+// Filters, sorting and pagination apply before fn, the envelope is applied around its result.
+// Lists aren't streamed with a custom response, also not with WithStreamingList.
+func WithCustomListResponse(fn func(ids []string, r *http.Request) interface{}) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.listResponse = fn
+		return nil
+	}
+}
+
+// listItems returns the bare ids, or objects describing each id if
+// binary mode is active or tags are requested via ?include_tags=true,
+// or the custom list response
+func (endpoint *Endpoint) listItems(r *http.Request, ids []string) interface{} {
+	if endpoint.listResponse != nil {
+		listed := make([]string, len(ids))
+		for i, id := range ids {
+			listed[i] = endpoint.listedID(id)
+		}
+		return endpoint.listResponse(listed, r)
+	}
+	includeTags := r.URL.Query().Get("include_tags") == "true"
+	if !endpoint.binary && !includeTags {
+		if endpoint.idPrefix == "" {
+			return ids
+		}
+		listed := make([]string, len(ids))
+		for i, id := range ids {
+			listed[i] = endpoint.listedID(id)
+		}
+		return listed
+	}
+	items := make([]map[string]interface{}, len(ids))
+	for i, id := range ids {
+		item := map[string]interface{}{"id": endpoint.listedID(id)}
+		if endpoint.binary {
+			item["content_type"] = endpoint.storedContentType(id)
+			item["size"] = endpoint.storedSize(id)
+		}
+		if includeTags {
+			item["tags"] = endpoint.loadTags(id)
+		}
+		items[i] = item
+	}
+	return items
+}
@@ -0,0 +1,49 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListItems", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store,
+			WithSortedList(Ascending),
+			WithCustomListResponse(func(ids []string, r *http.Request) interface{} {
+				items := make([]map[string]string, len(ids))
+				for i, id := range ids {
+					items[i] = map[string]string{"id": id, "href": "/test/" + id}
+				}
+				return map[string]interface{}{"items": items, "format": r.URL.Query().Get("format")}
+			}),
+		))
+		put(endpoint, "/b", `{}`)
+		put(endpoint, "/a", `{}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should encode the custom list response", func() {
+		code, data := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"format":"","items":[{"id":"a","href":"/test/a"},{"id":"b","href":"/test/b"}]}`))
+	})
+
+	It("should apply pagination before the custom response", func() {
+		code, data := get(endpoint, "/?limit=1&offset=1")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"format":"","items":[{"id":"b","href":"/test/b"}]}`))
+	})
+})
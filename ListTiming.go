@@ -0,0 +1,48 @@
+package crud
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// setDurationHeader sets a header to a duration in milliseconds
+func setDurationHeader(w http.ResponseWriter, name string, d time.Duration) {
+	w.Header().Set(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64))
+}
+
+// bufferedResponse collects a response to measure how long writing it takes before the headers are sent
+type bufferedResponse struct {
+	w      http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.w.Header()
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	if br.status == 0 {
+		br.status = status
+	}
+}
+
+func (br *bufferedResponse) Write(data []byte) (int, error) {
+	return br.body.Write(data)
+}
+
+// writeTimedList writes the list with the X-Encode-Duration-Ms header and X-List-Duration-Ms,
+// the total time since start
+func (endpoint *Endpoint) writeTimedList(w http.ResponseWriter, list interface{}, count int, start time.Time) {
+	encodeStart := time.Now()
+	br := &bufferedResponse{w: w}
+	endpoint.writeList(br, list, count)
+	setDurationHeader(w, "X-Encode-Duration-Ms", time.Since(encodeStart))
+	setDurationHeader(w, "X-List-Duration-Ms", time.Since(start))
+	if br.status != 0 {
+		w.WriteHeader(br.status)
+	}
+	w.Write(br.body.Bytes())
+}
@@ -0,0 +1,34 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListTiming", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should report the durations of list requests", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := NewEndpoint("test", store)
+		put(endpoint, "/key", `{}`)
+		resp := request(endpoint, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`["key"]`))
+		for _, header := range []string{"X-Store-Duration-Ms", "X-Transform-Duration-Ms", "X-Encode-Duration-Ms", "X-List-Duration-Ms"} {
+			ms, err := strconv.ParseFloat(resp.Header().Get(header), 64)
+			Expect(err).NotTo(HaveOccurred(), header)
+			Expect(ms).To(BeNumerically(">=", 0))
+		}
+	})
+})
@@ -0,0 +1,35 @@
+package crud
+
+import "net/http"
+
+// WithMaxBodySize limits request bodies to max bytes. Reading beyond the limit fails,
+// writes of objects and imports respond with 413 in that case. Gzip bodies and the files of imports are limited
+// after decompression as well.
+func WithMaxBodySize(max int64) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.maxBodySize = max
+		return nil
+	}
+}
+
+// isBodyTooLarge returns whether err was caused by exceeding the body size limit of http.MaxBytesReader
+func isBodyTooLarge(err error) bool {
+	return err != nil && err.Error() == "http: request body too large"
+}
+
+// bodyErrorStatus returns the status for an error which occurred while reading the request body
+func bodyErrorStatus(err error) int {
+	if isBodyTooLarge(err) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// isMalformedBody returns whether err was caused by a body which can't be decoded, like a broken gzip stream
+func isMalformedBody(err error) bool {
+	switch err.(type) {
+	case *gzipBodyError, *archiveFileError:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const defaultMaxConcurrentTimeout = 5 * time.Second
+
+// WithMaxConcurrent limits the number of concurrently handled requests to n.
+// Requests exceeding the limit wait for a free slot and fail with 503 after a timeout,
+// see WithMaxConcurrentTimeout. Fails if n is less than 1.
+func WithMaxConcurrent(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return fmt.Errorf("invalid concurrency limit %v", n)
+		}
+		endpoint.semaphore = make(chan struct{}, n)
+		return nil
+	}
+}
+
+// WithMaxConcurrentTimeout sets how long a request waits for a free slot, the default is 5s
+func WithMaxConcurrentTimeout(d time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.semTimeout = d
+		return nil
+	}
+}
+
+// ActiveRequests returns the number of requests which are currently handled
+func (endpoint *Endpoint) ActiveRequests() int {
+	return int(atomic.LoadInt64(&endpoint.active))
+}
+
+// acquire waits for a free slot. If none gets available in time 503 is written and false returned.
+func (endpoint *Endpoint) acquire(w http.ResponseWriter) bool {
+	if endpoint.semaphore == nil {
+		return true
+	}
+	timer := time.NewTimer(endpoint.semTimeout)
+	defer timer.Stop()
+	select {
+	case endpoint.semaphore <- struct{}{}:
+		return true
+	case <-timer.C:
+		w.Header().Set("Retry-After", "1")
+		endpoint.writeError(w, http.StatusServiceUnavailable, "too many concurrent requests")
+		return false
+	}
+}
+
+func (endpoint *Endpoint) release() {
+	if endpoint.semaphore != nil {
+		<-endpoint.semaphore
+	}
+}
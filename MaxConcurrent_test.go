@@ -0,0 +1,65 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaxConcurrent", func() {
+	var (
+		endpoint *Endpoint
+		block    chan struct{}
+	)
+
+	blocking := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Block") != "" {
+				<-block
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	BeforeEach(func() {
+		block = make(chan struct{})
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store,
+			WithMaxConcurrent(1),
+			WithMaxConcurrentTimeout(50*time.Millisecond),
+			WithMiddlewareChain(blocking),
+		))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should reject requests when all slots are taken", func() {
+		done := make(chan int)
+		go func() {
+			done <- request(endpoint, "PUT", "/key", "foobar", http.Header{"X-Block": {"yes"}}).Code
+		}()
+		Eventually(endpoint.ActiveRequests).Should(Equal(1))
+		resp := request(endpoint, "GET", "/key", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(resp.Header().Get("Retry-After")).NotTo(BeEmpty())
+		close(block)
+		Expect(<-done).To(Equal(http.StatusOK))
+		Expect(endpoint.ActiveRequests()).To(Equal(0))
+		code, _ := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid limits", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithMaxConcurrent(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,33 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// defaultMaxListSize is the maximum number of ids a list response contains if not configured otherwise
+const defaultMaxListSize = 10000
+
+// WithMaxListSize limits list responses to n ids, 0 disables the limit. The default is 10000.
+// Truncated responses carry the headers X-Truncated: true and X-Total-Count with the full count,
+// lists streamed while iterating the store only the trailer X-Truncated: true.
+func WithMaxListSize(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 0 {
+			return fmt.Errorf("max list size must not be negative, got %v", n)
+		}
+		endpoint.maxListSize = n
+		return nil
+	}
+}
+
+// truncateList cuts keys to the max list size and sets the truncation headers
+func (endpoint *Endpoint) truncateList(w http.ResponseWriter, keys []string) []string {
+	if endpoint.maxListSize == 0 || len(keys) <= endpoint.maxListSize {
+		return keys
+	}
+	w.Header().Set("X-Truncated", "true")
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(keys)))
+	return keys[:endpoint.maxListSize]
+}
@@ -0,0 +1,52 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaxListSize", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithMaxListSize(2)))
+		for i := 0; i < 3; i++ {
+			put(endpoint, "/key"+strconv.Itoa(i), "{}")
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should truncate list responses", func() {
+		resp := request(endpoint, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("X-Truncated")).To(Equal("true"))
+		Expect(resp.Header().Get("X-Total-Count")).To(Equal("3"))
+		var ids []string
+		Expect(json.Unmarshal(resp.Body.Bytes(), &ids)).To(Succeed())
+		Expect(ids).To(Equal([]string{"key0", "key1"}))
+	})
+
+	It("should not mark complete responses", func() {
+		resp := request(endpoint, "GET", "/?limit=2&offset=1", "", nil)
+		Expect(resp.Header().Get("X-Truncated")).To(BeEmpty())
+		Expect(resp.Header().Get("X-Total-Count")).To(BeEmpty())
+	})
+
+	It("should reject negative sizes", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithMaxListSize(-1))
+		Expect(err).To(HaveOccurred())
+	})
+})
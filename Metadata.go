@@ -0,0 +1,124 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// WithMetadata stores a meta sidecar with the creation and last modification time of each object.
+// The timestamps are added to the meta of enveloped responses and enable the
+// created_after and created_before filters of list requests.
+func WithMetadata() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.metadata = true
+		return nil
+	}
+}
+
+type objectMetadata struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// loadMetadata returns the metadata of an object and whether it exists
+func (endpoint *Endpoint) loadMetadata(id string) (objectMetadata, bool) {
+	var meta objectMetadata
+	data, err := endpoint.readSidecar(id, sidecarMeta)
+	if err != nil {
+		return meta, false
+	}
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+// touchMetadata sets the modification time of an object to now, and the creation time if it isn't set yet
+func (endpoint *Endpoint) touchMetadata(id string) error {
+	meta, _ := endpoint.loadMetadata(id)
+	now := time.Now().UTC()
+	if meta.CreatedAt.IsZero() {
+		meta.CreatedAt = now
+	}
+	meta.UpdatedAt = now
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return endpoint.writeSidecar(id, sidecarMeta, data)
+}
+
+// filterByCreated applies the created_after and created_before query parameters.
+// Every object's meta sidecar has to be read, so this is a full scan which gets slow for large endpoints.
+// Objects without metadata are excluded as soon as one of the parameters is given.
+func (endpoint *Endpoint) filterByCreated(r *http.Request, ids []string) ([]string, error) {
+	query := r.URL.Query()
+	after, err := parseTimeParam(query.Get("created_after"))
+	if err != nil {
+		return nil, errors.New("invalid created_after: " + err.Error())
+	}
+	before, err := parseTimeParam(query.Get("created_before"))
+	if err != nil {
+		return nil, errors.New("invalid created_before: " + err.Error())
+	}
+	if after.IsZero() && before.IsZero() {
+		return ids, nil
+	}
+	if !endpoint.metadata {
+		return nil, errors.New("created_after and created_before need metadata to be enabled")
+	}
+	result := ids[:0]
+	for _, id := range ids {
+		meta, ok := endpoint.loadMetadata(id)
+		if !ok {
+			continue
+		}
+		if !after.IsZero() && !meta.CreatedAt.After(after) {
+			continue
+		}
+		if !before.IsZero() && !meta.CreatedAt.Before(before) {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result, nil
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp, an empty value yields the zero time
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// handleMeta responds with the metadata of an object collected from its sidecars, without the body
+func (endpoint *Endpoint) handleMeta(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	if !endpoint.store.Has(endpoint.objectID(id)) || endpoint.isExpired(id) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	meta := map[string]interface{}{
+		"id":   id,
+		"tags": endpoint.loadTags(id),
+	}
+	if endpoint.binary || endpoint.defaultContentType != "" {
+		meta["content_type"] = endpoint.storedContentType(id)
+	}
+	if size := endpoint.storedSize(id); size >= 0 {
+		meta["size"] = size
+	}
+	if metadata, ok := endpoint.loadMetadata(id); ok {
+		meta["created_at"] = metadata.CreatedAt
+		meta["updated_at"] = metadata.UpdatedAt
+	}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, meta, endpoint.objectMeta(id))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meta)
+}
@@ -0,0 +1,105 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metadata", func() {
+	var (
+		store   streamstore.Storage
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithMetadata()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	listIDs := func(query string) []string {
+		code, data := get(handler, "/?"+query)
+		Expect(code).To(Equal(http.StatusOK))
+		var ids []string
+		Expect(json.Unmarshal([]byte(data), &ids)).To(Succeed())
+		return ids
+	}
+
+	It("should keep the creation time on updates", func() {
+		put(handler, "/key", `{"a":1}`)
+		envelope := mustEndpoint(NewEndpointWithOptions("test", store, WithMetadata(), WithResponseEnvelope()))
+		var first, second struct {
+			Meta struct {
+				CreatedAt time.Time `json:"created_at"`
+				UpdatedAt time.Time `json:"updated_at"`
+			} `json:"meta"`
+		}
+		_, data := get(envelope, "/key")
+		Expect(json.Unmarshal([]byte(data), &first)).To(Succeed())
+		Expect(first.Meta.CreatedAt.IsZero()).To(BeFalse())
+		patch(handler, "/key", `{"b":2}`)
+		_, data = get(envelope, "/key")
+		Expect(json.Unmarshal([]byte(data), &second)).To(Succeed())
+		Expect(second.Meta.CreatedAt).To(Equal(first.Meta.CreatedAt))
+		Expect(second.Meta.UpdatedAt).NotTo(BeTemporally("<", first.Meta.UpdatedAt))
+	})
+
+	It("should filter lists by creation time", func() {
+		put(handler, "/new", `{"a":1}`)
+		put(handler, "/old", `{"a":1}`)
+		writer, err := store.GetWriter("test::old::meta")
+		Expect(err).NotTo(HaveOccurred())
+		writer.Write([]byte(`{"created_at":"2020-01-01T00:00:00Z","updated_at":"2020-01-01T00:00:00Z"}`))
+		writer.Close()
+		Expect(listIDs("created_after=2024-01-01T00:00:00Z")).To(ConsistOf("new"))
+		Expect(listIDs("created_before=2024-01-01T00:00:00Z")).To(ConsistOf("old"))
+		Expect(listIDs("created_after=2019-01-01T00:00:00Z&created_before=2021-01-01T00:00:00Z")).To(ConsistOf("old"))
+		Expect(listIDs("")).To(ConsistOf("new", "old"))
+	})
+
+	It("should reject malformed timestamps", func() {
+		code, _ := get(handler, "/?created_after=yesterday")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should reject the filters without metadata", func() {
+		code, _ := get(NewEndpoint("test", store), "/?created_after=2024-01-01T00:00:00Z")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should return the metadata of an object", func() {
+		binary := mustEndpoint(NewEndpointWithOptions("test", store, WithMetadata(), WithBinaryMode()))
+		request(binary, "PUT", "/key", "foobar", http.Header{"Content-Type": {"text/plain"}, "X-Tags": {"a,b"}})
+		code, data := get(binary, "/meta/key")
+		Expect(code).To(Equal(http.StatusOK))
+		var meta map[string]interface{}
+		Expect(json.Unmarshal([]byte(data), &meta)).To(Succeed())
+		Expect(meta).To(HaveKeyWithValue("id", "key"))
+		Expect(meta).To(HaveKeyWithValue("content_type", "text/plain"))
+		Expect(meta).To(HaveKeyWithValue("size", 6.0))
+		Expect(meta).To(HaveKeyWithValue("tags", ConsistOf("a", "b")))
+		Expect(meta).To(HaveKey("created_at"))
+		Expect(meta).To(HaveKey("updated_at"))
+	})
+
+	It("should return 404 for missing objects", func() {
+		put(handler, "/key", `{"a":1}`)
+		Expect(store.Delete("test::key")).To(Succeed())
+		code, _ := get(handler, "/meta/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,129 @@
+package crud
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindow is the number of requests the average latency is computed from
+const latencyWindow = 1000
+
+// EndpointMetrics is a snapshot of the runtime statistics of an endpoint
+type EndpointMetrics struct {
+	TotalRequests     int64
+	TotalErrors       int64
+	TotalBytesRead    int64
+	TotalBytesWritten int64
+	// AverageLatencyMs is the average over the last 1000 requests
+	AverageLatencyMs float64
+	OperationCounts  map[string]int64
+	// OperationErrors counts the responses with status >= 500 per operation
+	OperationErrors map[string]int64
+	// OperationLatencyMs is the average latency per operation since the start
+	OperationLatencyMs map[string]float64
+	// MirrorFailures counts the failed and dropped requests of WithMirror
+	MirrorFailures int64
+	// StoreCallCounts and StoreLatencyMs are only filled with WithStoreTelemetry,
+	// keyed by store.list, store.read, store.write, store.delete and store.has
+	StoreCallCounts map[string]int64
+	// StoreLatencyMs is the average duration of the store calls since the start
+	StoreLatencyMs map[string]float64
+}
+
+type metrics struct {
+	requests int64
+	errors   int64
+	read     int64
+	written  int64
+
+	mirrorFailures int64
+
+	mutex      sync.Mutex
+	operations map[string]int64
+	opErrors   map[string]int64
+	opDuration map[string]time.Duration
+	latencies  []time.Duration
+	next       int
+
+	storeCalls     map[string]int64
+	storeDurations map[string]time.Duration
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		operations:     make(map[string]int64),
+		opErrors:       make(map[string]int64),
+		opDuration:     make(map[string]time.Duration),
+		latencies:      make([]time.Duration, 0, latencyWindow),
+		storeCalls:     make(map[string]int64),
+		storeDurations: make(map[string]time.Duration),
+	}
+}
+
+// recordStoreCall counts a store call and its duration
+func (m *metrics) recordStoreCall(label string, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.storeCalls[label]++
+	m.storeDurations[label] += duration
+}
+
+// record counts a finished request, responses with status >= 500 count as errors
+func (m *metrics) record(op Operation, status int, duration time.Duration, read, written int64) {
+	atomic.AddInt64(&m.requests, 1)
+	if status >= 500 {
+		atomic.AddInt64(&m.errors, 1)
+	}
+	atomic.AddInt64(&m.read, read)
+	atomic.AddInt64(&m.written, written)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.operations[string(op)]++
+	m.opDuration[string(op)] += duration
+	if status >= 500 {
+		m.opErrors[string(op)]++
+	}
+	if len(m.latencies) < latencyWindow {
+		m.latencies = append(m.latencies, duration)
+		return
+	}
+	m.latencies[m.next] = duration
+	m.next = (m.next + 1) % latencyWindow
+}
+
+// Metrics returns a snapshot of the runtime statistics of the endpoint
+func (endpoint *Endpoint) Metrics() EndpointMetrics {
+	m := endpoint.metrics
+	snapshot := EndpointMetrics{
+		TotalRequests:      atomic.LoadInt64(&m.requests),
+		TotalErrors:        atomic.LoadInt64(&m.errors),
+		TotalBytesRead:     atomic.LoadInt64(&m.read),
+		TotalBytesWritten:  atomic.LoadInt64(&m.written),
+		MirrorFailures:     atomic.LoadInt64(&m.mirrorFailures),
+		OperationCounts:    make(map[string]int64),
+		OperationErrors:    make(map[string]int64),
+		OperationLatencyMs: make(map[string]float64),
+		StoreCallCounts:    make(map[string]int64),
+		StoreLatencyMs:     make(map[string]float64),
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for op, count := range m.operations {
+		snapshot.OperationCounts[op] = count
+		snapshot.OperationErrors[op] = m.opErrors[op]
+		snapshot.OperationLatencyMs[op] = float64(m.opDuration[op]) / float64(count) / float64(time.Millisecond)
+	}
+	for label, count := range m.storeCalls {
+		snapshot.StoreCallCounts[label] = count
+		snapshot.StoreLatencyMs[label] = float64(m.storeDurations[label]) / float64(count) / float64(time.Millisecond)
+	}
+	if len(m.latencies) > 0 {
+		var sum time.Duration
+		for _, latency := range m.latencies {
+			sum += latency
+		}
+		snapshot.AverageLatencyMs = float64(sum) / float64(len(m.latencies)) / float64(time.Millisecond)
+	}
+	return snapshot
+}
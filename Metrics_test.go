@@ -0,0 +1,58 @@
+package crud_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingListStore struct {
+	streamstore.Storage
+}
+
+func (store failingListStore) List(prefix string) ([]string, error) {
+	return nil, errors.New("list failed")
+}
+
+var _ = Describe("Metrics", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", failingListStore{store})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should count requests, errors and bytes", func() {
+		put(endpoint, "/key", "foobar")
+		get(endpoint, "/key")
+		get(endpoint, "/key")
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		metrics := endpoint.Metrics()
+		Expect(metrics.TotalRequests).To(BeEquivalentTo(4))
+		Expect(metrics.TotalErrors).To(BeEquivalentTo(1))
+		Expect(metrics.TotalBytesRead).To(BeEquivalentTo(len("foobar")))
+		Expect(metrics.TotalBytesWritten).To(BeNumerically(">=", 2*len("foobar")))
+		Expect(metrics.AverageLatencyMs).To(BeNumerically(">", 0))
+		Expect(metrics.OperationCounts).To(Equal(map[string]int64{"put": 1, "get": 2, "list": 1}))
+	})
+
+	It("should return a copy", func() {
+		get(endpoint, "/key")
+		metrics := endpoint.Metrics()
+		metrics.OperationCounts["get"] = 100
+		Expect(endpoint.Metrics().OperationCounts["get"]).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,14 @@
+package crud
+
+import "net/http"
+
+// WithMiddlewareChain applies the given middlewares to each registered route.
+// Like gorilla/mux's Router.Use the middlewares only see requests which matched a route,
+// so routing errors like 404 and 405 are not passed through them.
+// The first middleware is the outermost one.
+func WithMiddlewareChain(middlewares ...func(http.Handler) http.Handler) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.middlewares = append(endpoint.middlewares, middlewares...)
+		return nil
+	}
+}
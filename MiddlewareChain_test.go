@@ -0,0 +1,62 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MiddlewareChain", func() {
+	var (
+		handler http.Handler
+		calls   []string
+	)
+
+	middleware := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				if r.Header.Get("X-Deny") != "" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	BeforeEach(func() {
+		calls = nil
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithMiddlewareChain(middleware("first"), middleware("second"))))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should apply the middlewares in order", func() {
+		code, _ := put(handler, "/key", "foobar")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(calls).To(Equal([]string{"first", "second"}))
+	})
+
+	It("should let middlewares intercept requests", func() {
+		resp := request(handler, "PUT", "/key", "foobar", http.Header{"X-Deny": {"yes"}})
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		code, _ := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not apply the middlewares to unrouted requests", func() {
+		code, _ := get(handler, "/key/wrong")
+		Expect(code).To(Equal(http.StatusNotFound))
+		Expect(calls).To(BeEmpty())
+	})
+})
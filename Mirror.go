@@ -0,0 +1,162 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// mirrorWorkers is the number of goroutines sending mirror requests
+	mirrorWorkers = 4
+	// mirrorQueueSize is the number of requests buffered per worker, further requests are dropped
+	mirrorQueueSize = 256
+	// defaultMirrorTimeout limits a mirror request unless WithMirrorTimeout is given
+	defaultMirrorTimeout = 10 * time.Second
+)
+
+// WithMirror replicates every successful write to the CRUD endpoint at upstream, e.g. in another cluster:
+// the same method, headers and body are sent to upstream + the request path in the background. A POST to the
+// collection is sent as PUT with the generated id, so both sides use the same id. Requests for the same path are
+// sent in order. Failed, timed out and dropped mirror requests are logged and counted in the metrics, the primary
+// response isn't affected. Mirrored request bodies are held in memory, limit them with WithMaxBodySize.
+func WithMirror(upstream string) Option {
+	return func(endpoint *Endpoint) error {
+		u, err := url.Parse(upstream)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("mirror %q is not an absolute url", upstream)
+		}
+		endpoint.mirrorUpstream = strings.TrimSuffix(upstream, "/")
+		return nil
+	}
+}
+
+// WithMirrorTimeout limits each mirror request to d, the default is 10s
+func WithMirrorTimeout(d time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if d <= 0 {
+			return errors.New("mirror timeout must be positive")
+		}
+		endpoint.mirrorTimeout = d
+		return nil
+	}
+}
+
+// mirrorRequest is a write waiting to be replicated
+type mirrorRequest struct {
+	method string
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// mirror sends the mirror requests, each path is always handled by the same worker to keep the order
+type mirror struct {
+	client  *http.Client
+	timeout time.Duration
+	queues  []chan *mirrorRequest
+	metrics *metrics
+}
+
+func newMirror(timeout time.Duration, m *metrics) *mirror {
+	mr := &mirror{client: http.DefaultClient, timeout: timeout, metrics: m}
+	for i := 0; i < mirrorWorkers; i++ {
+		queue := make(chan *mirrorRequest, mirrorQueueSize)
+		mr.queues = append(mr.queues, queue)
+		go mr.run(queue)
+	}
+	return mr
+}
+
+func (mr *mirror) run(queue chan *mirrorRequest) {
+	for req := range queue {
+		if err := mr.send(req); err != nil {
+			atomic.AddInt64(&mr.metrics.mirrorFailures, 1)
+			log.Warnf("failed to mirror %v %v: %v", req.method, req.url, err)
+		}
+	}
+}
+
+func (mr *mirror) send(req *mirrorRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mr.timeout)
+	defer cancel()
+	r, err := http.NewRequest(req.method, req.url, bytes.NewReader(req.body))
+	if err != nil {
+		return err
+	}
+	r = r.WithContext(ctx)
+	r.Header = req.header
+	resp, err := mr.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mirror responded with %v", resp.Status)
+	}
+	return nil
+}
+
+// add queues a request, it is dropped if the queue of its path is full
+func (mr *mirror) add(req *mirrorRequest, key string) {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	select {
+	case mr.queues[hash.Sum32()%mirrorWorkers] <- req:
+	default:
+		atomic.AddInt64(&mr.metrics.mirrorFailures, 1)
+		log.Warnf("dropped mirror request %v %v, the queue is full", req.method, req.url)
+	}
+}
+
+// withMirror wraps the handlers of mutating operations with the replication of successful requests
+func (endpoint *Endpoint) withMirror(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if endpoint.mirror == nil || endpoint.dryRun || !isMutating(op) {
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = ioutil.ReadAll(r.Body); err != nil {
+				endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		sw := newStatusWriter(w)
+		fn(sw, r)
+		if sw.status < 200 || sw.status >= 300 {
+			return
+		}
+		method, target := r.Method, r.URL.Path
+		if op == OperationPost {
+			location, err := url.Parse(sw.Header().Get("Location"))
+			if err != nil {
+				return
+			}
+			method, target = "PUT", strings.TrimSuffix(target, "/")+"/"+path.Base(location.EscapedPath())
+		}
+		u := endpoint.mirrorUpstream + target
+		if r.URL.RawQuery != "" {
+			u += "?" + r.URL.RawQuery
+		}
+		header := r.Header.Clone()
+		header.Del("Content-Length")
+		endpoint.mirror.add(&mirrorRequest{method: method, url: u, header: header, body: body}, target)
+	}
+}
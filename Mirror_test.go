@@ -0,0 +1,95 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mirror", func() {
+	var (
+		secondary *Endpoint
+		server    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test-mirror", nil)
+		Expect(err).NotTo(HaveOccurred())
+		secondary = NewEndpoint("test", store)
+		server = httptest.NewServer(secondary)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll("/tmp/test")
+		os.RemoveAll("/tmp/test-mirror")
+	})
+
+	primary := func(upstream string) *Endpoint {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		return mustEndpoint(NewEndpointWithOptions("test", store, WithMirror(upstream), WithMirrorTimeout(time.Second)))
+	}
+
+	It("should replicate writes", func() {
+		endpoint := primary(server.URL)
+		code, _ := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Eventually(func() string {
+			_, body := get(secondary, "/key")
+			return body
+		}).Should(MatchJSON(`{"foo":"bar"}`))
+
+		code, _ = del(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Eventually(func() int {
+			code, _ := get(secondary, "/key")
+			return code
+		}).Should(Equal(http.StatusNotFound))
+	})
+
+	It("should create posted objects with the same id", func() {
+		endpoint := primary(server.URL)
+		code, data := post(endpoint, "/", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusCreated))
+		result := map[string]string{}
+		Expect(json.Unmarshal([]byte(data), &result)).To(Succeed())
+		Eventually(func() string {
+			_, body := get(secondary, "/"+result["id"])
+			return body
+		}).Should(MatchJSON(`{"foo":"bar"}`))
+	})
+
+	It("should not replicate failed writes", func() {
+		endpoint := primary(server.URL)
+		code, _ := del(endpoint, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+		Consistently(func() int64 { return endpoint.Metrics().MirrorFailures }, 200*time.Millisecond).Should(BeZero())
+	})
+
+	It("should count mirror failures without affecting the response", func() {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failing.Close()
+		endpoint := primary(failing.URL)
+		code, _ := put(endpoint, "/key", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Eventually(func() int64 { return endpoint.Metrics().MirrorFailures }).Should(Equal(int64(1)))
+	})
+
+	It("should reject relative upstreams", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithMirror("/other"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,79 @@
+package crud
+
+import (
+	"io"
+	"net/http"
+	"path"
+)
+
+// handleMove renames an object to the id given by the "to" query parameter.
+// The source is only deleted after the object and its sidecars were copied successfully.
+// If the destination exists and the request carries "Overwrite: F" 409 is returned.
+func (endpoint *Endpoint) handleMove(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	to := r.URL.Query().Get("to")
+	if id == to {
+		endpoint.writeError(w, http.StatusBadRequest, "source and destination are the same")
+		return
+	}
+	if !endpoint.store.Has(endpoint.objectID(id)) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	old, overwritten := endpoint.eventSnapshot(id), endpoint.eventSnapshot(to)
+	if endpoint.store.Has(endpoint.objectID(to)) {
+		if r.Header.Get("Overwrite") == "F" {
+			endpoint.writeError(w, http.StatusConflict, "destination already exists")
+			return
+		}
+		if err := endpoint.deleteSidecars(to); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := endpoint.copyKey(endpoint.objectID(id), endpoint.objectID(to)); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, name := range sidecarNames {
+		if !endpoint.store.Has(endpoint.sidecarID(id, name)) {
+			continue
+		}
+		if err := endpoint.copyKey(endpoint.sidecarID(id, name), endpoint.sidecarID(to, name)); err != nil {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if err := endpoint.store.Delete(endpoint.objectID(id)); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := endpoint.deleteSidecars(id); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	endpoint.notify(OperationMove, id)
+	endpoint.notify(OperationMove, to)
+	endpoint.appendEvent(OperationMove, id, old, true)
+	endpoint.appendEvent(OperationMove, to, overwritten, false)
+	w.Header().Set("Location", endpoint.absoluteURL(r, path.Join(path.Dir(requestPath(r)), to), nil))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// copyKey copies the content stored under one key to another key
+func (endpoint *Endpoint) copyKey(from, to string) error {
+	reader, err := endpoint.store.GetReader(from)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	writer, err := endpoint.store.GetWriter(to)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
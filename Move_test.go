@@ -0,0 +1,76 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Move", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = NewEndpoint("test", store)
+		request(handler, "PUT", "/key1", "foobar", http.Header{"X-Tags": {"red"}})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	move := func(path string, header http.Header) int {
+		return request(handler, "MOVE", path, "", header).Code
+	}
+
+	It("should rename an object together with its sidecars", func() {
+		Expect(move("/key1?to=key2", nil)).To(Equal(http.StatusNoContent))
+		code, _ := get(handler, "/key1")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, data := get(handler, "/key2")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+		_, data = get(handler, "/?tag=red")
+		Expect(data).To(MatchJSON(`["key2"]`))
+	})
+
+	It("should overwrite existing objects by default", func() {
+		put(handler, "/key2", "other")
+		Expect(move("/key1?to=key2", nil)).To(Equal(http.StatusNoContent))
+		_, data := get(handler, "/key2")
+		Expect(data).To(Equal("foobar"))
+	})
+
+	It("should not overwrite existing objects with Overwrite: F", func() {
+		put(handler, "/key2", "other")
+		Expect(move("/key1?to=key2", http.Header{"Overwrite": {"F"}})).To(Equal(http.StatusConflict))
+		_, data := get(handler, "/key1")
+		Expect(data).To(Equal("foobar"))
+	})
+
+	It("should validate the ids", func() {
+		Expect(move("/key1", nil)).To(Equal(http.StatusBadRequest))
+		Expect(move("/key1?to=..", nil)).To(Equal(http.StatusBadRequest))
+		Expect(move("/key1?to=key2::tags", nil)).To(Equal(http.StatusBadRequest))
+		Expect(move("/missing?to=key2", nil)).To(Equal(http.StatusNotFound))
+	})
+})
+
+var _ = Describe("ValidateID", func() {
+	It("should accept normal ids", func() {
+		Expect(ValidateID("foo")).To(Succeed())
+		Expect(ValidateID("alice::profile")).To(Succeed())
+	})
+
+	It("should reject unsafe ids", func() {
+		for _, id := range []string{"", ".", "..", "a/b", "a\\b", "a\nb", "a::size"} {
+			Expect(ValidateID(id)).NotTo(Succeed(), id)
+		}
+	})
+})
@@ -0,0 +1,96 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultMaxMultiGet is the maximum number of ids of a multi get request if not configured otherwise
+const defaultMaxMultiGet = 100
+
+// WithMaxMultiGet limits the number of ids of a GET /?ids=<id1>,<id2> request. The default is 100.
+func WithMaxMultiGet(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return fmt.Errorf("max multi get must be at least 1, got %v", n)
+		}
+		endpoint.maxMultiGet = n
+		return nil
+	}
+}
+
+// handleMultiGet responds with a json map of id to object for all ids of the ids query parameter.
+// Missing, inaccessible or filtered objects are null, content which isn't json (and all content in binary mode)
+// is base64 encoded.
+func (endpoint *Endpoint) handleMultiGet(w http.ResponseWriter, r *http.Request) {
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	if len(ids) > endpoint.maxMultiGet {
+		endpoint.writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %v ids are allowed", endpoint.maxMultiGet))
+		return
+	}
+	for _, id := range ids {
+		if err := validateID(id, endpoint.delimiter); err != nil {
+			endpoint.writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid id %q: %v", id, err))
+			return
+		}
+		if !endpoint.matchesIDRegex(id) {
+			endpoint.writeError(w, http.StatusBadRequest, "id "+id+" doesn't match the naming convention")
+			return
+		}
+	}
+	var (
+		result = make(map[string]interface{}, len(ids))
+		mutex  sync.Mutex
+		wg     sync.WaitGroup
+	)
+	for _, id := range ids {
+		result[id] = nil
+	}
+	visible := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if endpoint.allowedID(id) && endpoint.mayAccess(r, OperationGet, id) && endpoint.authorized(r, OperationGet, id) && !endpoint.isExpired(id) {
+			visible = append(visible, id)
+		}
+	}
+	for _, id := range endpoint.filterByFunc(visible) {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			value, err := endpoint.multiGetValue(id)
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			result[id] = value
+			mutex.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// multiGetValue reads an object and returns it as json value or as []byte, which is encoded as base64.
+func (endpoint *Endpoint) multiGetValue(id string) (interface{}, error) {
+	content, err := endpoint.readContent(endpoint.objectID(id))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.binary || !json.Valid(content) {
+		return content, nil
+	}
+	if endpoint.computedFields != nil {
+		if content, err = endpoint.computeFields(id, bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+	return json.RawMessage(content), nil
+}
@@ -0,0 +1,49 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MultiGet", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithMaxMultiGet(3)))
+		put(endpoint, "/a", `{"foo":"bar"}`)
+		put(endpoint, "/b", `plain`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should return a map of the requested objects", func() {
+		code, body := get(endpoint, "/?ids=a,b,missing")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"a":{"foo":"bar"},"b":"cGxhaW4=","missing":null}`))
+	})
+
+	It("should reject too many ids", func() {
+		code, _ := get(endpoint, "/?ids=a,b,c,d")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should reject invalid ids", func() {
+		code, _ := get(endpoint, "/?ids=a,,b")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should reject invalid limits", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithMaxMultiGet(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,147 @@
+package crud
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/trusch/streamstore"
+)
+
+// defaultMaxTenants is the number of tenant endpoints kept by a multi tenant endpoint if not configured otherwise
+const defaultMaxTenants = 1000
+
+// errTooManyTenants is returned if all tenant endpoints are busy and no new one can be added
+var errTooManyTenants = errors.New("too many active tenants")
+
+// NewMultiTenantEndpoint constructs a handler which keeps the objects of each tenant apart: tenantExtractor returns
+// the tenant of a request, e.g. from a JWT claim, the subdomain or a header, and its objects are stored as
+// <tenant>::<prefix>::<id>. Requests get 400 if the tenant is empty, fails ValidateID or contains the delimiter.
+// Each tenant is served by its own endpoint with the given options, created on its first request. The access log,
+// body log, mirror, metrics and watchdog are shared by all tenants, while limits like throttling, max concurrent
+// requests and the object count limit apply per tenant. At most WithMaxTenants endpoints are kept, the least
+// recently used one without requests in progress is dropped for a new tenant; if all are busy the request gets 503.
+// It fails if the options are invalid.
+func NewMultiTenantEndpoint(prefix string, store streamstore.Storage, tenantExtractor func(*http.Request) string, opts ...Option) (http.Handler, error) {
+	if tenantExtractor == nil {
+		return nil, errors.New("no tenant extractor given")
+	}
+	base, err := NewEndpointWithOptions(prefix, store, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &multiTenantEndpoint{
+		base:      base,
+		prefix:    prefix,
+		store:     store,
+		opts:      opts,
+		extractor: tenantExtractor,
+		tenants:   make(map[string]*list.Element),
+		lru:       list.New(),
+	}, nil
+}
+
+// WithMaxTenants limits the number of tenant endpoints a multi tenant endpoint keeps to n. The default is 1000.
+func WithMaxTenants(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return fmt.Errorf("max tenants must be at least 1, got %v", n)
+		}
+		endpoint.maxTenants = n
+		return nil
+	}
+}
+
+type multiTenantEndpoint struct {
+	base      *Endpoint
+	prefix    string
+	store     streamstore.Storage
+	opts      []Option
+	extractor func(*http.Request) string
+	mutex     sync.Mutex
+	tenants   map[string]*list.Element
+	lru       *list.List
+}
+
+// tenantEntry is a tenant endpoint in the list of recently used tenants
+type tenantEntry struct {
+	tenant   string
+	endpoint *Endpoint
+}
+
+func (mt *multiTenantEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenant := mt.extractor(r)
+	if err := ValidateID(tenant); err != nil || strings.Contains(tenant, mt.base.delimiter) {
+		mt.base.writeError(w, http.StatusBadRequest, "invalid or missing tenant")
+		return
+	}
+	endpoint, err := mt.tenant(tenant)
+	if err == errTooManyTenants {
+		mt.base.writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		mt.base.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	endpoint.ServeHTTP(w, r)
+}
+
+// tenant returns the endpoint of a tenant, constructing it on first use
+func (mt *multiTenantEndpoint) tenant(tenant string) (*Endpoint, error) {
+	mt.mutex.Lock()
+	defer mt.mutex.Unlock()
+	if element, ok := mt.tenants[tenant]; ok {
+		mt.lru.MoveToFront(element)
+		return element.Value.(*tenantEntry).endpoint, nil
+	}
+	if len(mt.tenants) >= mt.base.maxTenants && !mt.evict() {
+		return nil, errTooManyTenants
+	}
+	endpoint, err := NewEndpointWithOptions(mt.prefix, mt.store, append(append([]Option(nil), mt.opts...), withTenant(tenant, mt.base))...)
+	if err != nil {
+		return nil, err
+	}
+	mt.tenants[tenant] = mt.lru.PushFront(&tenantEntry{tenant: tenant, endpoint: endpoint})
+	return endpoint, nil
+}
+
+// evict drops the least recently used tenant endpoint without requests in progress
+func (mt *multiTenantEndpoint) evict() bool {
+	for element := mt.lru.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*tenantEntry)
+		if atomic.LoadInt64(&entry.endpoint.active) > 0 {
+			continue
+		}
+		mt.lru.Remove(element)
+		delete(mt.tenants, entry.tenant)
+		if entry.endpoint.parent != nil {
+			entry.endpoint.parent.unregisterChild(entry.endpoint)
+		}
+		return true
+	}
+	return false
+}
+
+// withTenant stores the objects below the tenant, in front of the store prefix,
+// and shares the resources of the base endpoint
+func withTenant(tenant string, base *Endpoint) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.tenant = tenant
+		endpoint.tenantBase = base
+		return nil
+	}
+}
+
+// shareResources uses the logs, mirror, metrics and watchdog of base instead of creating them again
+func (endpoint *Endpoint) shareResources(base *Endpoint) {
+	endpoint.accessLog = base.accessLog
+	endpoint.bodyLog = base.bodyLog
+	endpoint.mirror = base.mirror
+	endpoint.metrics = base.metrics
+	endpoint.watchdog = base.watchdog
+}
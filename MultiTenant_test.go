@@ -0,0 +1,123 @@
+package crud_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// blockingReadStore blocks GetReader calls until release is closed
+type blockingReadStore struct {
+	streamstore.Storage
+	release chan struct{}
+}
+
+func (store blockingReadStore) GetReader(key string) (io.ReadCloser, error) {
+	<-store.release
+	return store.Storage.GetReader(key)
+}
+
+var _ = Describe("MultiTenant", func() {
+	var (
+		store   streamstore.Storage
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler, err = NewMultiTenantEndpoint("items", store, func(r *http.Request) string {
+			return r.Header.Get("X-Tenant")
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should keep the objects of tenants apart", func() {
+		acme, globex := http.Header{"X-Tenant": {"acme"}}, http.Header{"X-Tenant": {"globex"}}
+		Expect(request(handler, "PUT", "/key", `{"owner":"acme"}`, acme).Code).To(Equal(http.StatusOK))
+		Expect(store.Has("acme::items::key")).To(BeTrue())
+
+		Expect(request(handler, "GET", "/key", "", globex).Code).To(Equal(http.StatusNotFound))
+		resp := request(handler, "GET", "/", "", globex)
+		Expect(resp.Body.String()).To(MatchJSON(`[]`))
+
+		resp = request(handler, "GET", "/key", "", acme)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{"owner":"acme"}`))
+	})
+
+	It("should reject requests without valid tenant", func() {
+		Expect(request(handler, "GET", "/", "", nil).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(handler, "GET", "/", "", http.Header{"X-Tenant": {"../etc"}}).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(handler, "GET", "/", "", http.Header{"X-Tenant": {"a::b"}}).Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should share the watchdog between tenants", func() {
+		var fired int32
+		handler, err := NewMultiTenantEndpoint("items", store, func(r *http.Request) string {
+			return r.Header.Get("X-Tenant")
+		}, WithWatchdogTimer(50*time.Millisecond, func(*Endpoint) {
+			atomic.AddInt32(&fired, 1)
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		for _, tenant := range []string{"acme", "globex", "initech"} {
+			Expect(request(handler, "GET", "/", "", http.Header{"X-Tenant": {tenant}}).Code).To(Equal(http.StatusOK))
+		}
+		Eventually(func() int32 { return atomic.LoadInt32(&fired) }).Should(Equal(int32(1)))
+		Consistently(func() int32 { return atomic.LoadInt32(&fired) }, 150*time.Millisecond).Should(Equal(int32(1)))
+	})
+
+	It("should drop the least recently used tenant endpoints", func() {
+		handler, err := NewMultiTenantEndpoint("items", store, func(r *http.Request) string {
+			return r.Header.Get("X-Tenant")
+		}, WithMaxTenants(2))
+		Expect(err).NotTo(HaveOccurred())
+		for _, tenant := range []string{"acme", "globex", "initech", "acme"} {
+			header := http.Header{"X-Tenant": {tenant}}
+			Expect(request(handler, "PUT", "/key", `{"owner":"`+tenant+`"}`, header).Code).To(Equal(http.StatusOK))
+			resp := request(handler, "GET", "/key", "", header)
+			Expect(resp.Body.String()).To(MatchJSON(`{"owner":"` + tenant + `"}`))
+		}
+	})
+
+	It("should reject new tenants if all tenant endpoints are busy", func() {
+		blocking := blockingReadStore{Storage: store, release: make(chan struct{})}
+		handler, err := NewMultiTenantEndpoint("items", blocking, func(r *http.Request) string {
+			return r.Header.Get("X-Tenant")
+		}, WithMaxTenants(1))
+		Expect(err).NotTo(HaveOccurred())
+		done := make(chan int)
+		go func() {
+			done <- request(handler, "GET", "/key", "", http.Header{"X-Tenant": {"acme"}}).Code
+		}()
+		Eventually(func() int {
+			return request(handler, "GET", "/", "", http.Header{"X-Tenant": {"globex"}}).Code
+		}).Should(Equal(http.StatusServiceUnavailable))
+		close(blocking.release)
+		Eventually(done).Should(Receive(Equal(http.StatusNotFound)))
+		Expect(request(handler, "GET", "/", "", http.Header{"X-Tenant": {"globex"}}).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should fail with invalid options", func() {
+		_, err := NewMultiTenantEndpoint("items", store, func(*http.Request) string { return "" }, WithMaxListSize(-1))
+		Expect(err).To(HaveOccurred())
+		_, err = NewMultiTenantEndpoint("items", store, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = NewMultiTenantEndpoint("items", store, func(*http.Request) string { return "" }, WithMaxTenants(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,33 @@
+package crud
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// isNotFound returns whether a store error means that the requested key doesn't exist.
+// streamstore has no common error for this, so besides os.IsNotExist (file store) errors
+// with a NotFound() bool method and the messages of the other backends are recognized.
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	if os.IsNotExist(err) {
+		return true
+	}
+	if notFound, ok := err.(interface{ NotFound() bool }); ok {
+		return notFound.NotFound()
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "not exist") || strings.Contains(msg, "nosuchkey")
+}
+
+// writeStoreError responds with 404 if a store error means that the object doesn't exist, and with 500 otherwise
+func (endpoint *Endpoint) writeStoreError(w http.ResponseWriter, err error) {
+	if isNotFound(err) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,71 @@
+package crud_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// racingStore claims to have every object, like a store whose objects get deleted right after Has
+type racingStore struct {
+	streamstore.Storage
+}
+
+func (store racingStore) Has(id string) bool {
+	return true
+}
+
+// brokenStore fails every read and delete
+type brokenStore struct {
+	streamstore.Storage
+}
+
+func (store brokenStore) GetReader(id string) (io.ReadCloser, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (store brokenStore) Delete(id string) error {
+	return errors.New("connection refused")
+}
+
+var _ = Describe("NotFound", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should return 404 if objects disappear after the existence check", func() {
+		handler := NewEndpoint("test", racingStore{store})
+		code, _ := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = patch(handler, "/key", `{"a":1}`)
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = del(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return 500 for other store errors", func() {
+		handler := NewEndpoint("test", brokenStore{store})
+		code, _ := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		code, _ = patch(handler, "/key", `{"a":1}`)
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		code, _ = del(handler, "/key")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+	})
+})
@@ -0,0 +1,68 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// WithObjectCountLimit limits the number of objects of the endpoint to max. POST, and PUT of new objects, get
+// 507 Insufficient Storage when the limit is reached, as do the files of an import with new ids beyond it.
+// Every such write lists the store, and concurrent writes can exceed the limit slightly.
+func WithObjectCountLimit(max int) Option {
+	return func(endpoint *Endpoint) error {
+		if max < 1 {
+			return fmt.Errorf("object count limit must be at least 1, got %v", max)
+		}
+		endpoint.objectCountLimit = max
+		return nil
+	}
+}
+
+// checkObjectCount writes 507 and returns false if there is no room for another object
+func (endpoint *Endpoint) checkObjectCount(w http.ResponseWriter) bool {
+	if endpoint.objectCountLimit == 0 {
+		return true
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	if len(ids) >= endpoint.objectCountLimit {
+		endpoint.writeError(w, http.StatusInsufficientStorage, fmt.Sprintf("object count limit of %v reached", endpoint.objectCountLimit))
+		return false
+	}
+	return true
+}
+
+// objectCapacity counts the objects which can still be created
+type objectCapacity struct {
+	remaining int64
+}
+
+// remainingCapacity returns the number of objects which can still be created, or nil without an object count limit
+func (endpoint *Endpoint) remainingCapacity() (*objectCapacity, error) {
+	if endpoint.objectCountLimit == 0 {
+		return nil, nil
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		return nil, err
+	}
+	return &objectCapacity{remaining: int64(endpoint.objectCountLimit - len(ids))}, nil
+}
+
+// take reserves room for one object, it returns false if there is none left
+func (c *objectCapacity) take() bool {
+	if atomic.AddInt64(&c.remaining, -1) >= 0 {
+		return true
+	}
+	c.release()
+	return false
+}
+
+// release returns the room of an object which wasn't created
+func (c *objectCapacity) release() {
+	atomic.AddInt64(&c.remaining, 1)
+}
@@ -0,0 +1,81 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ObjectCountLimit", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectCountLimit(3)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should allow exactly max objects", func() {
+		for i := 0; i < 3; i++ {
+			code, _ := post(endpoint, "/", `{}`)
+			Expect(code).To(Equal(http.StatusCreated))
+		}
+		code, _ := post(endpoint, "/", `{}`)
+		Expect(code).To(Equal(http.StatusInsufficientStorage))
+		code, _ = put(endpoint, "/new", `{}`)
+		Expect(code).To(Equal(http.StatusInsufficientStorage))
+	})
+
+	It("should allow updates when the limit is reached", func() {
+		put(endpoint, "/a", `{}`)
+		put(endpoint, "/b", `{}`)
+		resp := request(endpoint, "PUT", "/c", `{}`, http.Header{"X-Tags": {"x"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		code, _ := put(endpoint, "/a", `{"updated":true}`)
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject imported files with new ids beyond the limit", func() {
+		put(endpoint, "/a", `{}`)
+		resp := request(endpoint, "PUT", "/import", zipArchive(map[string]string{
+			"a.json": `{"updated":true}`,
+			"b.json": `{}`,
+			"c.json": `{}`,
+			"d.json": `{}`,
+		}), http.Header{"Content-Type": {"application/zip"}})
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		var result struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+			Errors []struct {
+				Status int `json:"status"`
+			} `json:"errors"`
+		}
+		Expect(json.Unmarshal(resp.Body.Bytes(), &result)).To(Succeed())
+		Expect(result.Results).To(HaveLen(3))
+		Expect(result.Errors).To(HaveLen(1))
+		Expect(result.Errors[0].Status).To(Equal(http.StatusInsufficientStorage))
+		_, data := get(endpoint, "/a")
+		Expect(data).To(MatchJSON(`{"updated":true}`))
+		_, data = get(endpoint, "/")
+		var ids []string
+		Expect(json.Unmarshal([]byte(data), &ids)).To(Succeed())
+		Expect(ids).To(HaveLen(3))
+	})
+
+	It("should reject invalid limits", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithObjectCountLimit(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,63 @@
+package crud
+
+import (
+	"io"
+	"net/http"
+
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// WithObjectSizeLimit rejects objects larger than max bytes with 413.
+// POST and PUT bodies are checked while they are written to a staging key, a previously stored
+// version is only replaced once the whole body was accepted. Merged PATCH results are checked before
+// they are written, so the stored object stays untouched.
+func WithObjectSizeLimit(max int64) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.objectSizeLimit = max
+		return nil
+	}
+}
+
+// stageWrites returns whether a body may be rejected while it is written, because of a size limit
+// or a malformed gzip stream. In that case it is written below a staging key first, so a rejected
+// write can't destroy the stored object. In dry run mode nothing is written, so the body is checked
+// while it is discarded.
+func (endpoint *Endpoint) stageWrites(r *http.Request) bool {
+	if endpoint.dryRun {
+		return false
+	}
+	return endpoint.objectSizeLimit > 0 || endpoint.maxBodySize > 0 || isGzipBody(r)
+}
+
+// stagingID returns a unique store key to stage a write of an object under.
+// It ends with a reserved sidecar name, so it is never listed.
+func (endpoint *Endpoint) stagingID(id string) string {
+	return endpoint.sidecarID(id, uuid.NewV4().String()+endpoint.delimiter+sidecarUpload)
+}
+
+// commitStaged copies a staged write to the object and removes the staging key
+func (endpoint *Endpoint) commitStaged(key, id string) error {
+	defer endpoint.discardStaged(key)
+	reader, err := endpoint.store.GetReader(key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	writer, err := endpoint.store.GetWriter(endpoint.objectID(id))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// discardStaged removes a staged write
+func (endpoint *Endpoint) discardStaged(key string) {
+	if err := endpoint.store.Delete(key); err != nil && !isNotFound(err) {
+		log.Errorf("failed to delete staged write %v: %v", key, err)
+	}
+}
@@ -0,0 +1,63 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ObjectSizeLimit", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectSizeLimit(16)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should accept objects within the limit", func() {
+		code, _ := put(handler, "/key", strings.Repeat("x", 16))
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject and remove oversized objects", func() {
+		code, _ := put(handler, "/key", strings.Repeat("x", 17))
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		code, _ = get(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = post(handler, "/", strings.Repeat("x", 17))
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		_, data := get(handler, "/")
+		Expect(data).To(MatchJSON(`[]`))
+	})
+
+	It("should keep the stored version if an oversized object is rejected", func() {
+		code, _ := put(handler, "/key", `{"v":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = put(handler, "/key", strings.Repeat("x", 17))
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		code, data := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"v":1}`))
+		_, data = get(handler, "/")
+		Expect(data).To(MatchJSON(`["key"]`))
+	})
+
+	It("should reject patches which grow the object too much", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, _ := patch(handler, "/key", `{"b":"some long value"}`)
+		Expect(code).To(Equal(http.StatusRequestEntityTooLarge))
+		_, data := get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"a":1}`))
+	})
+})
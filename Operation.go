@@ -0,0 +1,39 @@
+package crud
+
+// Operation identifies the kind of request handled by an endpoint
+type Operation string
+
+const (
+	// OperationList lists the ids of all objects
+	OperationList Operation = "list"
+	// OperationQuery lists the ids of the objects matching a query body
+	OperationQuery Operation = "query"
+	// OperationGet reads a single object
+	OperationGet Operation = "get"
+	// OperationGetMeta reads the metadata of a single object
+	OperationGetMeta Operation = "get_meta"
+	// OperationWatch streams change events
+	OperationWatch Operation = "watch"
+	// OperationStats reads the request statistics
+	OperationStats Operation = "stats"
+	// OperationPost creates an object with a generated id
+	OperationPost Operation = "post"
+	// OperationPut creates or replaces an object
+	OperationPut Operation = "put"
+	// OperationPatch merges fields into a json object
+	OperationPatch Operation = "patch"
+	// OperationPatchAll applies a json patch to all objects
+	OperationPatchAll Operation = "patch_all"
+	// OperationDelete deletes an object
+	OperationDelete Operation = "delete"
+	// OperationDeleteAll deletes all objects
+	OperationDeleteAll Operation = "delete_all"
+	// OperationImport stores the files of a zip archive
+	OperationImport Operation = "import"
+	// OperationMove renames an object
+	OperationMove Operation = "move"
+	// OperationAddTags adds tags to an object
+	OperationAddTags Operation = "add_tags"
+	// OperationDeleteTag removes a tag from an object
+	OperationDeleteTag Operation = "delete_tag"
+)
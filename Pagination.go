@@ -0,0 +1,110 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// paginate applies the offset and limit query parameters to the list of keys.
+// If a limit is requested a RFC 5988 Link header pointing to the first, previous, next and last page is set.
+// Alternatively page and page_size select a page, combining both styles is an error.
+func (endpoint *Endpoint) paginate(w http.ResponseWriter, r *http.Request, keys []string) ([]string, error) {
+	query := r.URL.Query()
+	if query.Get("page") != "" || query.Get("page_size") != "" {
+		if query.Get("limit") != "" || query.Get("offset") != "" {
+			return nil, errors.New("page and page_size can't be combined with offset and limit")
+		}
+		return paginatePages(w, r, keys)
+	}
+	if query.Get("limit") == "" && query.Get("offset") == "" {
+		return keys, nil
+	}
+	offset, err := parseNonNegative(query.Get("offset"), 0)
+	if err != nil {
+		return nil, fmt.Errorf("malformed offset: %v", err)
+	}
+	limit, err := parseNonNegative(query.Get("limit"), len(keys))
+	if err != nil {
+		return nil, fmt.Errorf("malformed limit: %v", err)
+	}
+	total := len(keys)
+	if limit > 0 {
+		links := []string{endpoint.pageLink(r, 0, limit, "first")}
+		if offset > 0 {
+			prev := offset - limit
+			if prev < 0 {
+				prev = 0
+			}
+			links = append(links, endpoint.pageLink(r, prev, limit, "prev"))
+		}
+		if offset+limit < total {
+			links = append(links, endpoint.pageLink(r, offset+limit, limit, "next"))
+		}
+		last := 0
+		if total > 0 {
+			last = (total - 1) / limit * limit
+		}
+		links = append(links, endpoint.pageLink(r, last, limit, "last"))
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return keys[offset:end], nil
+}
+
+// paginatePages applies the 1-indexed page and the page_size query parameters to the list of keys
+// and sets the X-Current-Page, X-Total-Pages and X-Total-Count headers.
+func paginatePages(w http.ResponseWriter, r *http.Request, keys []string) ([]string, error) {
+	query := r.URL.Query()
+	page, err := parseNonNegative(query.Get("page"), 1)
+	if err != nil || page == 0 {
+		return nil, errors.New("malformed page: must be a number >= 1")
+	}
+	size, err := parseNonNegative(query.Get("page_size"), 0)
+	if err != nil || size == 0 {
+		return nil, errors.New("malformed page_size: must be a number >= 1")
+	}
+	total := len(keys)
+	pages := (total + size - 1) / size
+	w.Header().Set("X-Current-Page", strconv.Itoa(page))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(pages))
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	start := (page - 1) * size
+	if start > total {
+		start = total
+	}
+	end := start + size
+	if end > total {
+		end = total
+	}
+	return keys[start:end], nil
+}
+
+func (endpoint *Endpoint) pageLink(r *http.Request, offset, limit int, rel string) string {
+	query := r.URL.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	return fmt.Sprintf(`<%v>; rel="%v"`, endpoint.absoluteURL(r, requestPath(r), query), rel)
+}
+
+func parseNonNegative(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return n, nil
+}
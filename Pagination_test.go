@@ -0,0 +1,119 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = NewEndpoint("test", store)
+		for i := 0; i < 10; i++ {
+			put(handler, fmt.Sprintf("/key%v", i), "foobar")
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	parseLinks := func(header string) map[string]string {
+		links := make(map[string]string)
+		for _, match := range regexp.MustCompile(`<([^>]*)>; rel="(\w+)"`).FindAllStringSubmatch(header, -1) {
+			links[match[2]] = match[1]
+		}
+		return links
+	}
+
+	decode := func(data string) []string {
+		list := []string{}
+		Expect(json.Unmarshal([]byte(data), &list)).To(Succeed())
+		return list
+	}
+
+	It("should limit the list", func() {
+		code, data := get(handler, "/?limit=3")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(decode(data)).To(HaveLen(3))
+	})
+
+	It("should set a link header which can be followed", func() {
+		resp := request(handler, "GET", "/?limit=4", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		first := decode(resp.Body.String())
+		links := parseLinks(resp.Header().Get("Link"))
+		Expect(links).To(HaveKey("first"))
+		Expect(links).To(HaveKey("next"))
+		Expect(links).To(HaveKey("last"))
+		Expect(links).NotTo(HaveKey("prev"))
+
+		next, err := url.Parse(links["next"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(next.Query().Get("offset")).To(Equal("4"))
+		resp = request(handler, "GET", next.RequestURI(), "", nil)
+		second := decode(resp.Body.String())
+		Expect(second).To(HaveLen(4))
+		for _, key := range second {
+			Expect(first).NotTo(ContainElement(key))
+		}
+		links = parseLinks(resp.Header().Get("Link"))
+		Expect(links).To(HaveKey("prev"))
+
+		last, err := url.Parse(links["last"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(last.Query().Get("offset")).To(Equal("8"))
+		resp = request(handler, "GET", last.RequestURI(), "", nil)
+		Expect(decode(resp.Body.String())).To(HaveLen(2))
+		Expect(parseLinks(resp.Header().Get("Link"))).NotTo(HaveKey("next"))
+	})
+
+	It("should reject malformed parameters", func() {
+		code, _ := get(handler, "/?limit=foo")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = get(handler, "/?offset=-1")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should serve pages", func() {
+		seen := []string{}
+		sizes := []int{}
+		for page := 1; page <= 4; page++ {
+			resp := request(handler, "GET", fmt.Sprintf("/?page=%v&page_size=3", page), "", nil)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("X-Current-Page")).To(Equal(fmt.Sprint(page)))
+			Expect(resp.Header().Get("X-Total-Pages")).To(Equal("4"))
+			Expect(resp.Header().Get("X-Total-Count")).To(Equal("10"))
+			list := decode(resp.Body.String())
+			sizes = append(sizes, len(list))
+			seen = append(seen, list...)
+		}
+		Expect(sizes).To(Equal([]int{3, 3, 3, 1}))
+		Expect(seen).To(HaveLen(10))
+		for i := 0; i < 10; i++ {
+			Expect(seen).To(ContainElement(fmt.Sprintf("key%v", i)))
+		}
+		_, data := get(handler, "/?page=5&page_size=3")
+		Expect(decode(data)).To(BeEmpty())
+	})
+
+	It("should reject mixed pagination styles", func() {
+		code, _ := get(handler, "/?page=1&limit=3")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = get(handler, "/?page=0&page_size=3")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+})
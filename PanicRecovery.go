@@ -0,0 +1,41 @@
+package crud
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WithPanicRecovery recovers from panics in handlers and stores. fn is called with the object id of the request
+// and the recovered value, then the request gets 500 with a json error body unless the response was already started.
+// A nil fn logs the panic with its stack trace, see DefaultPanicHandler.
+func WithPanicRecovery(fn func(id string, recovered interface{})) Option {
+	return func(endpoint *Endpoint) error {
+		if fn == nil {
+			fn = DefaultPanicHandler
+		}
+		endpoint.panicHandler = fn
+		return nil
+	}
+}
+
+// DefaultPanicHandler logs a recovered panic with its stack trace on error level
+func DefaultPanicHandler(id string, recovered interface{}) {
+	log.WithField("id", id).Errorf("panic while handling request: %v\n%s", recovered, debug.Stack())
+}
+
+// serve runs the handler, recovering from panics if panic recovery is enabled
+func (endpoint *Endpoint) serve(handler http.Handler, sw *statusWriter, r *http.Request) {
+	if endpoint.panicHandler != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				endpoint.panicHandler(endpoint.requestID(r), recovered)
+				if !sw.wroteHeader {
+					endpoint.writeEnvelopedError(sw, http.StatusInternalServerError, "internal error")
+				}
+			}
+		}()
+	}
+	handler.ServeHTTP(sw, r)
+}
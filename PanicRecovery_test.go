@@ -0,0 +1,51 @@
+package crud_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// panickingStore panics when reading
+type panickingStore struct {
+	streamstore.Storage
+}
+
+func (store panickingStore) GetReader(id string) (io.ReadCloser, error) {
+	panic("store is broken")
+}
+
+var _ = Describe("PanicRecovery", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should recover from store panics", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		var (
+			panicID   string
+			recovered interface{}
+		)
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", panickingStore{store}, WithMaxConcurrent(1),
+			WithPanicRecovery(func(id string, r interface{}) {
+				panicID, recovered = id, r
+			}),
+		))
+		resp := request(endpoint, "GET", "/key", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":{"message":"internal error"},"meta":{}}`))
+		Expect(panicID).To(Equal("key"))
+		Expect(recovered).To(Equal("store is broken"))
+		Expect(endpoint.ActiveRequests()).To(Equal(0))
+		code, _ := put(endpoint, "/other", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,148 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WithParentResource makes the objects of this endpoint children of the objects of a parent endpoint.
+// POST and PUT bodies (and PATCH bodies changing the field) must be json objects whose foreignKey field
+// holds the id of an existing parent object as string or integral number, otherwise 422 is returned.
+// Combine it with WithCascadeDelete to delete the children together with their parent.
+func WithParentResource(parent *Endpoint, foreignKey string) Option {
+	return func(endpoint *Endpoint) error {
+		if parent == nil || foreignKey == "" {
+			return fmt.Errorf("parent resource needs an endpoint and a foreign key")
+		}
+		endpoint.parent = parent
+		endpoint.foreignKey = foreignKey
+		return nil
+	}
+}
+
+// WithCascadeDelete deletes all children referencing a parent object when the parent object is deleted.
+// Finding the children is a full scan over this endpoint. It needs WithParentResource.
+func WithCascadeDelete() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.cascadeDelete = true
+		return nil
+	}
+}
+
+// registerChild is called once a child endpoint was constructed successfully
+func (endpoint *Endpoint) registerChild(child *Endpoint) {
+	endpoint.childrenMutex.Lock()
+	defer endpoint.childrenMutex.Unlock()
+	endpoint.children = append(endpoint.children, child)
+}
+
+// unregisterChild removes a child endpoint which is no longer served
+func (endpoint *Endpoint) unregisterChild(child *Endpoint) {
+	endpoint.childrenMutex.Lock()
+	defer endpoint.childrenMutex.Unlock()
+	for i, registered := range endpoint.children {
+		if registered == child {
+			endpoint.children = append(endpoint.children[:i], endpoint.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// checkParent writes 422 and returns false if the foreign key of the body doesn't reference an existing parent
+func (endpoint *Endpoint) checkParent(w http.ResponseWriter, r *http.Request) bool {
+	if endpoint.parent == nil {
+		return true
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	obj := make(map[string]interface{})
+	if err = json.Unmarshal(data, &obj); err != nil {
+		endpoint.writeUnmarshalError(w, &unmarshalError{err: err})
+		return false
+	}
+	return endpoint.checkForeignKey(w, obj[endpoint.foreignKey])
+}
+
+// checkForeignKey writes 422 and returns false if value doesn't reference an existing parent
+func (endpoint *Endpoint) checkForeignKey(w http.ResponseWriter, value interface{}) bool {
+	id, ok := foreignKeyID(value)
+	if !ok || validateID(id, endpoint.parent.delimiter) != nil {
+		endpoint.writeError(w, http.StatusUnprocessableEntity, "foreign key must be a valid id string or an integer")
+		return false
+	}
+	if !endpoint.parent.store.Has(endpoint.parent.objectID(id)) {
+		endpoint.writeError(w, http.StatusUnprocessableEntity, "parent object not found")
+		return false
+	}
+	return true
+}
+
+// foreignKeyID returns the parent id a foreign key value references. Only strings and integral numbers are ids,
+// numbers are formatted without exponent, so 1e3 references the parent 1000.
+func foreignKeyID(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case float64:
+		if value != math.Trunc(value) || math.IsInf(value, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// deleteChildren applies the cascading deletes of the child endpoints after a parent object was deleted
+func (endpoint *Endpoint) deleteChildren(parentID string) error {
+	endpoint.childrenMutex.Lock()
+	children := append([]*Endpoint(nil), endpoint.children...)
+	endpoint.childrenMutex.Unlock()
+	for _, child := range children {
+		if !child.cascadeDelete {
+			continue
+		}
+		if err := child.deleteChildrenOf(parentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteChildrenOf deletes all objects of this endpoint referencing the given parent
+func (endpoint *Endpoint) deleteChildrenOf(parentID string) error {
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		obj, _, err := endpoint.readJSONObject(endpoint.objectID(id))
+		if err != nil {
+			log.Warnf("skipping unreadable child %v in cascading delete: %v", id, err)
+			continue
+		}
+		if id, ok := foreignKeyID(obj[endpoint.foreignKey]); !ok || id != parentID {
+			continue
+		}
+		if err = endpoint.store.Delete(endpoint.objectID(id)); err != nil {
+			return err
+		}
+		if err = endpoint.deleteSidecars(id); err != nil {
+			return err
+		}
+		if err = endpoint.deleteChildren(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParentResource", func() {
+	var posts, comments *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		posts = NewEndpoint("posts", store)
+		comments = mustEndpoint(NewEndpointWithOptions("comments", store, WithParentResource(posts, "post_id"), WithCascadeDelete()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should require an existing parent", func() {
+		code, _ := put(comments, "/c1", `{"post_id":"p1"}`)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+		code, _ = post(comments, "/", `{"text":"no parent"}`)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+		put(posts, "/p1", `{}`)
+		code, _ = put(comments, "/c1", `{"post_id":"p1"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = patch(comments, "/c1", `{"post_id":"p2"}`)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	It("should only accept strings and integers as foreign keys", func() {
+		put(posts, "/1000", `{}`)
+		code, _ := put(comments, "/c1", `{"post_id":1e3}`)
+		Expect(code).To(Equal(http.StatusOK))
+		for _, value := range []string{`1000.5`, `true`, `{"id":1000}`, `["1000"]`, `null`, `"../1000"`, `""`} {
+			code, _ = put(comments, "/c2", `{"post_id":`+value+`}`)
+			Expect(code).To(Equal(http.StatusUnprocessableEntity), value)
+		}
+		code, _ = del(posts, "/1000")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(comments, "/c1")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should delete children with their parent", func() {
+		put(posts, "/p1", `{}`)
+		put(posts, "/p2", `{}`)
+		put(comments, "/c1", `{"post_id":"p1"}`)
+		put(comments, "/c2", `{"post_id":"p2"}`)
+		code, _ := del(posts, "/p1")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(comments, "/c1")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = get(comments, "/c2")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,132 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// patchAllResult is the outcome of patching one object
+type patchAllResult struct {
+	ID     string      `json:"id"`
+	Status int         `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+// handlePatchAll applies a RFC 6902 JSON Patch (application/json-patch+json) to every object of the endpoint.
+// Each object is read, patched and written only if it didn't change in between. With ?dry_run=true nothing
+// is written and the patched objects are returned instead. It responds with 207 and {"results":[...]}.
+func (endpoint *Endpoint) handlePatchAll(w http.ResponseWriter, r *http.Request) {
+	if endpoint.binary {
+		endpoint.writeError(w, http.StatusMethodNotAllowed, "patch is not supported in binary mode")
+		return
+	}
+	if r.Body == nil {
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
+		return
+	}
+	if !sameMediaType(r.Header.Get("Content-Type"), "application/json-patch+json") {
+		endpoint.writeError(w, http.StatusUnsupportedMediaType, "unsupported content type, expected application/json-patch+json")
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return
+	}
+	if err = endpoint.checkJSONLimits(data); err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	patch, err := parseJSONPatch(data)
+	if _, ok := err.(*unmarshalError); ok {
+		endpoint.writeUnmarshalError(w, err)
+		return
+	}
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, "invalid json patch: "+err.Error())
+		return
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	results := make([]patchAllResult, 0, len(ids))
+	ids = endpoint.filterAuthorized(r, OperationPatchAll, endpoint.filterAccessible(r, OperationPatchAll, ids))
+	for _, id := range ids {
+		results = append(results, endpoint.patchObject(r, id, patch, dryRun))
+	}
+	result := map[string]interface{}{"results": results}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusMultiStatus, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (endpoint *Endpoint) patchObject(r *http.Request, id string, patch jsonPatch, dryRun bool) patchAllResult {
+	result := patchAllResult{ID: id}
+	fail := func(status int, msg string) patchAllResult {
+		result.Status = status
+		result.Error = msg
+		return result
+	}
+	objectID := endpoint.objectID(id)
+	content, err := endpoint.readContent(objectID)
+	if err != nil {
+		if isNotFound(err) {
+			return fail(http.StatusNotFound, "object not found")
+		}
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+	var doc interface{}
+	if err = json.Unmarshal(content, &doc); err != nil {
+		return fail(http.StatusUnprocessableEntity, "object is not valid json")
+	}
+	if doc, err = patch.apply(doc); err != nil {
+		return fail(http.StatusUnprocessableEntity, err.Error())
+	}
+	patched := new(bytes.Buffer)
+	if err = json.NewEncoder(patched).Encode(doc); err != nil {
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+	if endpoint.objectSizeLimit > 0 && int64(patched.Len()) > endpoint.objectSizeLimit {
+		return fail(http.StatusRequestEntityTooLarge, "object too large")
+	}
+	if endpoint.schemas != nil {
+		if err = endpoint.schemas.Validate(endpoint.schemaContentType(r, id), patched.Bytes()); err != nil {
+			return fail(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+	if dryRun {
+		result.Status = http.StatusOK
+		result.Result = doc
+		return result
+	}
+	if err = endpoint.writeIfMatch(objectID, computeETag(content), patched.Bytes()); err != nil {
+		if err == errPreconditionFailed {
+			return fail(http.StatusConflict, "object was modified concurrently")
+		}
+		return fail(http.StatusInternalServerError, err.Error())
+	}
+	if endpoint.metadata {
+		if err = endpoint.touchMetadata(id); err != nil {
+			return fail(http.StatusInternalServerError, err.Error())
+		}
+	}
+	if endpoint.upstream != "" {
+		if err = endpoint.writeThrough("PUT", id, patched.Bytes(), "application/json"); err != nil {
+			return fail(http.StatusBadGateway, err.Error())
+		}
+	}
+	endpoint.notify(OperationPatchAll, id)
+	endpoint.appendEvent(OperationPatchAll, id, content, false)
+	result.Status = http.StatusOK
+	return result
+}
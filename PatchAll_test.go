@@ -0,0 +1,90 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PatchAll", func() {
+	var handler http.Handler
+	jsonPatch := http.Header{"Content-Type": {"application/json-patch+json"}}
+
+	type results struct {
+		Results []struct {
+			ID     string          `json:"id"`
+			Status int             `json:"status"`
+			Error  string          `json:"error"`
+			Result json.RawMessage `json:"result"`
+		} `json:"results"`
+	}
+
+	patchAll := func(path, body string) results {
+		resp := request(handler, "PATCH", path, body, jsonPatch)
+		Expect(resp.Code).To(Equal(http.StatusMultiStatus))
+		var res results
+		Expect(json.Unmarshal(resp.Body.Bytes(), &res)).To(Succeed())
+		return res
+	}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = NewEndpoint("test", store)
+		put(handler, "/a", `{"name":"a","old":1,"list":[1,2]}`)
+		put(handler, "/b", `{"name":"b","old":2,"list":[]}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should patch all objects", func() {
+		res := patchAll("/", `[
+			{"op":"add","path":"/version","value":2},
+			{"op":"move","from":"/old","path":"/new"},
+			{"op":"add","path":"/list/-","value":{"x":1}}
+		]`)
+		Expect(res.Results).To(HaveLen(2))
+		for _, result := range res.Results {
+			Expect(result.Status).To(Equal(http.StatusOK))
+		}
+		_, data := get(handler, "/a")
+		Expect(data).To(MatchJSON(`{"name":"a","new":1,"version":2,"list":[1,2,{"x":1}]}`))
+		_, data = get(handler, "/b")
+		Expect(data).To(MatchJSON(`{"name":"b","new":2,"version":2,"list":[{"x":1}]}`))
+	})
+
+	It("should report per object failures", func() {
+		res := patchAll("/", `[{"op":"test","path":"/name","value":"a"},{"op":"remove","path":"/old"}]`)
+		statuses := map[string]int{}
+		for _, result := range res.Results {
+			statuses[result.ID] = result.Status
+		}
+		Expect(statuses).To(Equal(map[string]int{"a": http.StatusOK, "b": http.StatusUnprocessableEntity}))
+		_, data := get(handler, "/b")
+		Expect(data).To(MatchJSON(`{"name":"b","old":2,"list":[]}`))
+	})
+
+	It("should not write in dry runs", func() {
+		res := patchAll("/?dry_run=true", `[{"op":"replace","path":"/name","value":"x"}]`)
+		Expect(res.Results).To(HaveLen(2))
+		for _, result := range res.Results {
+			Expect(result.Status).To(Equal(http.StatusOK))
+			Expect(string(result.Result)).To(ContainSubstring(`"name":"x"`))
+		}
+		_, data := get(handler, "/a")
+		Expect(data).To(MatchJSON(`{"name":"a","old":1,"list":[1,2]}`))
+	})
+
+	It("should reject invalid patches", func() {
+		Expect(request(handler, "PATCH", "/", `[{"op":"explode","path":"/a"}]`, jsonPatch).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(handler, "PATCH", "/", `[]`, nil).Code).To(Equal(http.StatusUnsupportedMediaType))
+	})
+})
@@ -0,0 +1,19 @@
+package crud
+
+import (
+	"net/http"
+	"strings"
+)
+
+// prefersMinimal returns whether the request has the preference "Prefer: return=minimal" (RFC 7240)
+func prefersMinimal(r *http.Request) bool {
+	for _, header := range r.Header["Prefer"] {
+		for _, preference := range strings.Split(header, ",") {
+			token := strings.TrimSpace(strings.SplitN(preference, ";", 2)[0])
+			if strings.EqualFold(strings.Replace(token, " ", "", -1), "return=minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prefer", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+		put(endpoint, "/key", `{"foo":"bar"}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond with 204 to PATCH with return=minimal", func() {
+		resp := request(endpoint, "PATCH", "/key", `{"baz":1}`, http.Header{"Prefer": {"respond-async, return=minimal"}})
+		Expect(resp.Code).To(Equal(http.StatusNoContent))
+		Expect(resp.Header().Get("Preference-Applied")).To(Equal("return=minimal"))
+		Expect(resp.Body.Len()).To(BeZero())
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"bar","baz":1}`))
+	})
+
+	It("should echo the merged object otherwise", func() {
+		resp := request(endpoint, "PATCH", "/key", `{"baz":1}`, http.Header{"Prefer": {"return=representation"}})
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Preference-Applied")).To(BeEmpty())
+		Expect(resp.Body.String()).To(MatchJSON(`{"foo":"bar","baz":1}`))
+	})
+})
@@ -0,0 +1,136 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/trusch/streamstore"
+)
+
+// defaultUpstreamTimeout limits a request to the upstream of a proxy endpoint unless WithUpstreamTimeout is given
+const defaultUpstreamTimeout = 10 * time.Second
+
+// NewProxyEndpoint constructs an endpoint which caches the objects of an upstream CRUD service in the local store.
+// GET requests for objects missing in the store are fetched from upstream + "/" + id and stored locally.
+// Writes and deletes are applied to the local store and to the upstream service.
+// The objects are stored with the upstream host and path as prefix.
+// It fails if upstream is not an absolute URL.
+func NewProxyEndpoint(upstream string, store streamstore.Storage, opts ...Option) (*Endpoint, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("upstream %q is not an absolute url", upstream)
+	}
+	prefix := u.Host + strings.Replace(u.Path, "/", "_", -1)
+	opts = append([]Option{withUpstream(upstream)}, opts...)
+	return NewEndpointWithOptions(prefix, store, opts...)
+}
+
+func withUpstream(upstream string) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.upstream = strings.TrimSuffix(upstream, "/")
+		return nil
+	}
+}
+
+// WithUpstreamTimeout limits each request of a proxy endpoint to its upstream, including reading the response body,
+// to d. The default is 10s. A GET whose fetch times out gets 502, just like a write-through that times out.
+func WithUpstreamTimeout(d time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if d <= 0 {
+			return errors.New("upstream timeout must be positive")
+		}
+		endpoint.upstreamTimeout = d
+		return nil
+	}
+}
+
+func (endpoint *Endpoint) upstreamURL(id string) string {
+	return endpoint.upstream + "/" + url.PathEscape(id)
+}
+
+// fetchUpstream copies an object from upstream into the local store
+func (endpoint *Endpoint) fetchUpstream(id string) (bool, error) {
+	resp, err := endpoint.client.Get(endpoint.upstreamURL(id))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("upstream responded with %v", resp.Status)
+	}
+	writer, err := endpoint.store.GetWriter(endpoint.objectID(id))
+	if err != nil {
+		return false, err
+	}
+	size, err := io.Copy(writer, resp.Body)
+	if err != nil {
+		// don't serve a partial copy, e.g. after a timeout, from the cache
+		writer.Close()
+		endpoint.store.Delete(endpoint.objectID(id))
+		return false, err
+	}
+	if err = writer.Close(); err != nil {
+		return false, err
+	}
+	r := &http.Request{Header: http.Header{"Content-Type": resp.Header["Content-Type"]}}
+	return true, endpoint.saveSidecars(r, id, size)
+}
+
+// writeThrough applies a write or delete to the upstream service
+func (endpoint *Endpoint) writeThrough(method, id string, body []byte, contentType string) error {
+	if endpoint.dryRun {
+		return nil
+	}
+	req, err := http.NewRequest(method, endpoint.upstreamURL(id), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := endpoint.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if method == "DELETE" && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("upstream responded with %v", resp.Status)
+	}
+	return nil
+}
+
+// deleteUpstreamOnly deletes an object which is not cached locally
+func (endpoint *Endpoint) deleteUpstreamOnly(w http.ResponseWriter, id string) {
+	req, err := http.NewRequest("DELETE", endpoint.upstreamURL(id), nil)
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp, err := endpoint.client.Do(req)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		endpoint.writeError(w, http.StatusBadGateway, fmt.Sprintf("upstream responded with %v", resp.Status))
+	}
+}
@@ -0,0 +1,124 @@
+package crud_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProxyEndpoint", func() {
+	var (
+		upstream http.Handler
+		server   *httptest.Server
+		handler  http.Handler
+	)
+
+	BeforeEach(func() {
+		upstreamStore, err := uriparser.NewFromURI("file:///tmp/test-upstream", nil)
+		Expect(err).NotTo(HaveOccurred())
+		upstream = NewEndpoint("test", upstreamStore)
+		server = httptest.NewServer(upstream)
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewProxyEndpoint(server.URL, store))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll("/tmp/test")
+		os.RemoveAll("/tmp/test-upstream")
+	})
+
+	It("should fetch missing objects from upstream and cache them", func() {
+		put(upstream, "/key", "foobar")
+		code, data := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+		del(upstream, "/key")
+		code, data = get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+	})
+
+	It("should return 404 if upstream does not know the object", func() {
+		code, _ := get(handler, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should write through to upstream", func() {
+		code, _ := put(handler, "/key", "foobar")
+		Expect(code).To(Equal(http.StatusOK))
+		code, data := get(upstream, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+	})
+
+	It("should write patches through to upstream", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, _ := patch(handler, "/key", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusOK))
+		_, data := get(upstream, "/key")
+		Expect(data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should delete from both stores", func() {
+		put(handler, "/key", "foobar")
+		code, _ := del(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(upstream, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = get(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should delete objects which are only known upstream", func() {
+		put(upstream, "/key", "foobar")
+		code, _ := del(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		code, _ = get(upstream, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = del(handler, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should time out hanging upstream requests", func() {
+		release := make(chan struct{})
+		hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "GET" {
+				w.Write([]byte("partial"))
+				w.(http.Flusher).Flush()
+			}
+			<-release
+		}))
+		defer hanging.Close()
+		defer close(release)
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewProxyEndpoint(hanging.URL, store, WithUpstreamTimeout(50*time.Millisecond)))
+		start := time.Now()
+		code, _ := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusBadGateway))
+		code, _ = get(handler, "/key")
+		Expect(code).To(Equal(http.StatusBadGateway))
+		code, _ = put(handler, "/key", "foobar")
+		Expect(code).To(Equal(http.StatusBadGateway))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("should reject invalid upstream timeouts", func() {
+		_, err := NewProxyEndpoint(server.URL, nil, WithUpstreamTimeout(0))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject relative upstream urls", func() {
+		_, err := NewProxyEndpoint("example.com/test", nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,95 @@
+package crud
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// query is the body of a QUERY / request
+type query struct {
+	Filter map[string]interface{} `json:"filter"`
+	Sort   string                 `json:"sort"`
+	Limit  *int                   `json:"limit"`
+	Offset int                    `json:"offset"`
+}
+
+// handleQuery serves QUERY / (draft-ietf-httpbis-safe-method-w-body), which lists ids like GET / but takes
+// the parameters as json body: {"filter":{"<field>":<value>},"sort":"<field>:<asc|desc>","limit":N,"offset":M}.
+// The filter matches objects whose top level fields equal all given values, so every object has to be read.
+// The response carries X-Total-Count with the number of matches before pagination.
+func (endpoint *Endpoint) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if endpoint.binary {
+		endpoint.writeError(w, http.StatusMethodNotAllowed, "query is not supported in binary mode")
+		return
+	}
+	if r.Body == nil {
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
+		return
+	}
+	var q query
+	if err := endpoint.decodeJSONBody(r.Body, &q); err != nil {
+		if _, ok := err.(*unmarshalError); ok {
+			endpoint.writeUnmarshalError(w, err)
+			return
+		}
+		endpoint.writeError(w, bodyErrorStatus(err), err.Error())
+		return
+	}
+	if q.Offset < 0 || (q.Limit != nil && *q.Limit < 0) {
+		endpoint.writeError(w, http.StatusBadRequest, "offset and limit must not be negative")
+		return
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ids = endpoint.filterAccessible(r, OperationQuery, ids)
+	ids = endpoint.filterExpired(ids)
+	ids = endpoint.filterByFunc(ids)
+	if len(q.Filter) > 0 {
+		ids = endpoint.filterByFields(ids, q.Filter)
+	}
+	if q.Sort != "" {
+		if err = endpoint.sortByField(ids, q.Sort); err != nil {
+			endpoint.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		endpoint.sortKeys(ids)
+	}
+	total := len(ids)
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	start, end := q.Offset, total
+	if start > total {
+		start = total
+	}
+	if q.Limit != nil && start+*q.Limit < end {
+		end = start + *q.Limit
+	}
+	ids = endpoint.truncateList(w, ids[start:end])
+	endpoint.writeList(w, endpoint.listItems(r, ids), len(ids))
+}
+
+// filterByFields returns the ids of the json objects whose top level fields equal the given values
+func (endpoint *Endpoint) filterByFields(ids []string, filter map[string]interface{}) []string {
+	result := ids[:0]
+	for _, id := range ids {
+		obj, _, err := endpoint.readJSONObject(endpoint.objectID(id))
+		if err != nil {
+			continue
+		}
+		matches := true
+		for field, value := range filter {
+			if actual, ok := obj[field]; !ok || !reflect.DeepEqual(actual, value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, id)
+		}
+	}
+	return result
+}
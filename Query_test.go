@@ -0,0 +1,63 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+		for i := 0; i < 6; i++ {
+			put(endpoint, fmt.Sprintf("/key%v", i), fmt.Sprintf(`{"kind":"%v","n":%v}`, []string{"a", "b"}[i%2], i))
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	query := func(body string) (int, []string) {
+		resp := request(endpoint, "QUERY", "/", body, http.Header{"Content-Type": {"application/json"}})
+		ids := []string{}
+		if resp.Code == http.StatusOK {
+			Expect(json.Unmarshal(resp.Body.Bytes(), &ids)).To(Succeed())
+		}
+		return resp.Code, ids
+	}
+
+	It("should filter by field values", func() {
+		code, ids := query(`{"filter":{"kind":"a"}}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(ids).To(Equal([]string{"key0", "key2", "key4"}))
+	})
+
+	It("should sort and paginate", func() {
+		code, ids := query(`{"filter":{"kind":"b"},"sort":"n:desc","offset":1,"limit":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(ids).To(Equal([]string{"key3"}))
+		resp := request(endpoint, "QUERY", "/", `{"filter":{"kind":"b"},"limit":1}`, nil)
+		Expect(resp.Header().Get("X-Total-Count")).To(Equal("3"))
+	})
+
+	It("should reject malformed queries", func() {
+		code, _ := query(`{"filter":`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = query(`{"limit":-1}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = query(`{"sort":"n:up"}`)
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+})
@@ -0,0 +1,82 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// WithReadCoalescing makes concurrent GET requests for the same object share a single store read.
+// The first request buffers the object and all requests arriving while it is read get the same bytes
+// (or the same error). Nothing is cached beyond that, the next request after the read finished reads again.
+// This helps against read bursts on hot objects, but each shared object is held in memory completely.
+func WithReadCoalescing() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.readFlights = &readGroup{calls: make(map[string]*readCall)}
+		return nil
+	}
+}
+
+// readCall is a store read in progress, or completed
+type readCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// readGroup deduplicates concurrent reads of the same key
+type readGroup struct {
+	mutex sync.Mutex
+	calls map[string]*readCall
+}
+
+// read calls fn unless a read of the key is already in progress, in which case it waits for that read's result
+func (g *readGroup) read(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mutex.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	call := &readCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	// like singleflight the waiters are released even if fn panics, they get the panic as error
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			call.err = fmt.Errorf("read of %v panicked: %v", key, recovered)
+		}
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	call.data, call.err = fn()
+	return call.data, call.err
+}
+
+// getReader returns a reader of the stored object, shared with concurrent requests if reads are coalesced
+func (endpoint *Endpoint) getReader(objectID string) (io.ReadCloser, error) {
+	if endpoint.readFlights == nil {
+		return endpoint.store.GetReader(objectID)
+	}
+	data, err := endpoint.readFlights.read(objectID, func() ([]byte, error) {
+		reader, err := endpoint.store.GetReader(objectID)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,120 @@
+package crud_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// slowReadStore counts the GetReader calls and delays them
+type slowReadStore struct {
+	streamstore.Storage
+	reads int32
+}
+
+func (store *slowReadStore) GetReader(key string) (io.ReadCloser, error) {
+	atomic.AddInt32(&store.reads, 1)
+	time.Sleep(100 * time.Millisecond)
+	return store.Storage.GetReader(key)
+}
+
+// panicReadStore panics in the first GetReader call, after the given delay
+type panicReadStore struct {
+	streamstore.Storage
+	delay    time.Duration
+	panicked int32
+}
+
+func (store *panicReadStore) GetReader(key string) (io.ReadCloser, error) {
+	if atomic.CompareAndSwapInt32(&store.panicked, 0, 1) {
+		time.Sleep(store.delay)
+		panic("store failure")
+	}
+	return store.Storage.GetReader(key)
+}
+
+var _ = Describe("ReadCoalescing", func() {
+	var (
+		slow     *slowReadStore
+		endpoint *Endpoint
+	)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		slow = &slowReadStore{Storage: store}
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", slow, WithReadCoalescing()))
+		put(endpoint, "/hot", `{"foo":"bar"}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should share one store read between concurrent requests", func() {
+		var wg sync.WaitGroup
+		bodies := make([]string, 10)
+		for i := range bodies {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				var code int
+				code, bodies[i] = get(endpoint, "/hot")
+				Expect(code).To(Equal(http.StatusOK))
+			}(i)
+		}
+		wg.Wait()
+		Expect(atomic.LoadInt32(&slow.reads)).To(BeNumerically("<", 3))
+		for _, body := range bodies {
+			Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		}
+
+		get(endpoint, "/hot")
+		Expect(atomic.LoadInt32(&slow.reads)).To(BeNumerically(">", 1))
+	})
+
+	It("should share not found errors", func() {
+		code, _ := get(endpoint, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should release concurrent requests if a read panics", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		panicking := &panicReadStore{Storage: store, delay: 100 * time.Millisecond}
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", panicking, WithReadCoalescing(), WithPanicRecovery(func(string, interface{}) {})))
+		put(endpoint, "/hot", `{"foo":"bar"}`)
+		var wg sync.WaitGroup
+		codes := make([]int, 2)
+		for i := range codes {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				codes[i], _ = get(endpoint, "/hot")
+			}(i)
+			time.Sleep(10 * time.Millisecond)
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		Eventually(done).Should(BeClosed())
+		Expect(codes).To(Equal([]int{http.StatusInternalServerError, http.StatusInternalServerError}))
+		code, body := get(endpoint, "/hot")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+	})
+})
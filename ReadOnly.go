@@ -0,0 +1,21 @@
+package crud
+
+import "net/http"
+
+// WithReadOnly rejects all operations which change objects with 405, only list, get, meta and watch requests are served.
+func WithReadOnly() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.readOnly = true
+		return nil
+	}
+}
+
+// withReadOnly wraps the handlers of mutating operations of read-only endpoints
+func (endpoint *Endpoint) withReadOnly(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if !endpoint.readOnly || !isMutating(op) {
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		endpoint.writeError(w, http.StatusMethodNotAllowed, "endpoint is read-only")
+	}
+}
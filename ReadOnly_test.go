@@ -0,0 +1,46 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadOnly", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		put(NewEndpoint("test", store), "/key", `{"foo":"bar"}`)
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithReadOnly()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve reads", func() {
+		code, body := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		code, _ = get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject writes", func() {
+		code, _ := put(endpoint, "/key", `{}`)
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+		code, _ = post(endpoint, "/", `{}`)
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+		code, _ = del(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+	})
+})
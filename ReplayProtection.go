@@ -0,0 +1,76 @@
+package crud
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// WithReplayProtection requires mutating requests to carry a unique X-Nonce header with a uuid.
+// Nonces are remembered for the given window, a nonce which is seen again within the window gets 409 Conflict.
+// Requests without a valid nonce get 400. Nonces are only remembered per endpoint and process.
+func WithReplayProtection(window time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.nonces = newNonceCache(window)
+		return nil
+	}
+}
+
+// nonceCache remembers nonces until their window expired
+type nonceCache struct {
+	mutex     sync.Mutex
+	window    time.Duration
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newNonceCache(window time.Duration) *nonceCache {
+	return &nonceCache{
+		window: window,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// add remembers a nonce and returns false if it was already seen within the window
+func (cache *nonceCache) add(nonce string, now time.Time) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if now.Sub(cache.lastPrune) > cache.window {
+		for key, expiry := range cache.seen {
+			if !now.Before(expiry) {
+				delete(cache.seen, key)
+			}
+		}
+		cache.lastPrune = now
+	}
+	if expiry, ok := cache.seen[nonce]; ok && now.Before(expiry) {
+		return false
+	}
+	cache.seen[nonce] = now.Add(cache.window)
+	return true
+}
+
+func isMutating(op Operation) bool {
+	return op != OperationList && op != OperationQuery && op != OperationGet && op != OperationGetMeta && op != OperationWatch && op != OperationStats
+}
+
+// withReplayProtection wraps a handler with the nonce check
+func (endpoint *Endpoint) withReplayProtection(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	if endpoint.nonces == nil || !isMutating(op) {
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		nonce := r.Header.Get("X-Nonce")
+		if _, err := uuid.FromString(nonce); err != nil {
+			endpoint.writeError(w, http.StatusBadRequest, "missing or invalid X-Nonce header")
+			return
+		}
+		if !endpoint.nonces.add(nonce, time.Now()) {
+			endpoint.writeError(w, http.StatusConflict, "nonce was already used")
+			return
+		}
+		fn(w, r)
+	}
+}
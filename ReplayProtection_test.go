@@ -0,0 +1,46 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReplayProtection", func() {
+	var handler http.Handler
+	nonce := http.Header{"X-Nonce": {"5f0b33b2-6a1e-4d39-9d6b-0f1c2a3b4c5d"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithReplayProtection(50*time.Millisecond)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should reject replayed requests", func() {
+		Expect(request(handler, "PUT", "/key", "foobar", nonce).Code).To(Equal(http.StatusOK))
+		Expect(request(handler, "PUT", "/key", "foobar", nonce).Code).To(Equal(http.StatusConflict))
+		Expect(request(handler, "DELETE", "/key", "", nonce).Code).To(Equal(http.StatusConflict))
+	})
+
+	It("should forget nonces after the window", func() {
+		Expect(request(handler, "PUT", "/key", "foobar", nonce).Code).To(Equal(http.StatusOK))
+		time.Sleep(60 * time.Millisecond)
+		Expect(request(handler, "PUT", "/key", "foobar", nonce).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should require a nonce for mutating requests only", func() {
+		Expect(request(handler, "PUT", "/key", "foobar", nil).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(handler, "PUT", "/key", "foobar", http.Header{"X-Nonce": {"once"}}).Code).To(Equal(http.StatusBadRequest))
+		Expect(request(handler, "GET", "/", "", nil).Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,24 @@
+package crud
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestLogger is called after each request with the resulting status, the duration and the number of written bytes
+type RequestLogger func(r *http.Request, status int, duration time.Duration, bytesWritten int64)
+
+// DefaultAccessLogger logs each request on debug level using logrus
+func DefaultAccessLogger(r *http.Request, status int, duration time.Duration, bytesWritten int64) {
+	log.Debugf("%v request to %v", r.Method, r.URL)
+}
+
+// WithRequestLogger replaces the DefaultAccessLogger with a custom access log function
+func WithRequestLogger(fn func(r *http.Request, status int, duration time.Duration, bytesWritten int64)) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.logger = fn
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RequestLogger", func() {
+	type entry struct {
+		method   string
+		status   int
+		duration time.Duration
+		written  int64
+	}
+
+	var (
+		handler http.Handler
+		entries []entry
+	)
+
+	BeforeEach(func() {
+		entries = nil
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithRequestLogger(func(r *http.Request, status int, duration time.Duration, written int64) {
+			entries = append(entries, entry{r.Method, status, duration, written})
+		})))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should log status and written bytes of each request", func() {
+		put(handler, "/key", "foobar")
+		get(handler, "/key")
+		get(handler, "/missing")
+		Expect(entries).To(HaveLen(3))
+		Expect(entries[0].method).To(Equal("PUT"))
+		Expect(entries[0].status).To(Equal(http.StatusOK))
+		Expect(entries[1].method).To(Equal("GET"))
+		Expect(entries[1].written).To(BeEquivalentTo(len("foobar")))
+		Expect(entries[2].status).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not log requests which match no route", func() {
+		get(handler, "/key/wrong")
+		Expect(entries).To(BeEmpty())
+	})
+})
@@ -0,0 +1,75 @@
+package crud
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// WithResponseEnvelope wraps every response body in {"data":..., "meta":{...}}.
+// Errors are returned as {"error":{"message":...}, "meta":{}}.
+// Stored objects which are not valid json are base64 encoded.
+func WithResponseEnvelope() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.envelope = true
+		return nil
+	}
+}
+
+type envelope struct {
+	Data interface{}            `json:"data"`
+	Meta map[string]interface{} `json:"meta"`
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Meta map[string]interface{} `json:"meta"`
+}
+
+func (endpoint *Endpoint) objectMeta(id string) map[string]interface{} {
+	meta := map[string]interface{}{
+		"id":     id,
+		"prefix": endpoint.prefix,
+	}
+	if endpoint.metadata {
+		if metadata, ok := endpoint.loadMetadata(id); ok {
+			meta["created_at"] = metadata.CreatedAt
+			meta["updated_at"] = metadata.UpdatedAt
+		}
+	}
+	return meta
+}
+
+func (endpoint *Endpoint) writeEnvelope(w http.ResponseWriter, status int, data interface{}, meta map[string]interface{}) {
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(envelope{Data: data, Meta: meta})
+}
+
+func (endpoint *Endpoint) writeEnvelopedError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	response := errorEnvelope{Meta: make(map[string]interface{})}
+	response.Error.Message = msg
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeEnvelopedObject embeds json objects as is and base64 encodes everything else
+func (endpoint *Endpoint) writeEnvelopedObject(w http.ResponseWriter, id string, reader io.Reader) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var data interface{} = content
+	if json.Valid(content) {
+		data = json.RawMessage(content)
+	}
+	endpoint.writeEnvelope(w, http.StatusOK, data, endpoint.objectMeta(id))
+}
@@ -0,0 +1,98 @@
+package crud_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResponseEnvelope", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithResponseEnvelope()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	type response struct {
+		Data  json.RawMessage        `json:"data"`
+		Meta  map[string]interface{} `json:"meta"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	decode := func(data string) response {
+		var resp response
+		Expect(json.Unmarshal([]byte(data), &resp)).To(Succeed())
+		return resp
+	}
+
+	It("should wrap json objects", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, data := get(handler, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		resp := decode(data)
+		Expect(resp.Data).To(MatchJSON(`{"a":1}`))
+		Expect(resp.Meta).To(HaveKeyWithValue("id", "key"))
+		Expect(resp.Meta).To(HaveKeyWithValue("prefix", "test"))
+	})
+
+	It("should base64 encode raw data", func() {
+		put(handler, "/key", "foobar")
+		_, data := get(handler, "/key")
+		var content string
+		Expect(json.Unmarshal(decode(data).Data, &content)).To(Succeed())
+		Expect(content).To(Equal(base64.StdEncoding.EncodeToString([]byte("foobar"))))
+	})
+
+	It("should wrap post and put responses", func() {
+		code, data := post(handler, "/", "foobar")
+		Expect(code).To(Equal(http.StatusCreated))
+		id := struct{ ID string }{}
+		Expect(json.Unmarshal(decode(data).Data, &id)).To(Succeed())
+		Expect(id.ID).NotTo(BeEmpty())
+		code, data = put(handler, "/key", "foobar")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(decode(data).Data).To(MatchJSON(`{"id":"key"}`))
+	})
+
+	It("should wrap list responses", func() {
+		put(handler, "/key1", "foobar")
+		put(handler, "/key2", "foobar")
+		_, data := get(handler, "/")
+		resp := decode(data)
+		Expect(resp.Meta).To(HaveKeyWithValue("count", BeNumerically("==", 2)))
+		list := []string{}
+		Expect(json.Unmarshal(resp.Data, &list)).To(Succeed())
+		Expect(list).To(ConsistOf("key1", "key2"))
+	})
+
+	It("should wrap patch responses", func() {
+		put(handler, "/key", `{"a":1}`)
+		code, data := patch(handler, "/key", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(decode(data).Data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+
+	It("should wrap errors", func() {
+		code, data := get(handler, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+		resp := decode(data)
+		Expect(resp.Error).NotTo(BeNil())
+		Expect(resp.Error.Message).To(Equal("object not found"))
+		Expect(resp.Meta).To(BeEmpty())
+	})
+})
@@ -0,0 +1,52 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// writeError writes an error response, either as plain text or enveloped
+func (endpoint *Endpoint) writeError(w http.ResponseWriter, status int, msg string) {
+	if endpoint.envelope {
+		endpoint.writeEnvelopedError(w, status, msg)
+		return
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(msg))
+}
+
+// WithLegacyResponseBodies restores the old behavior of POST and PUT which respond with the bare id instead of {"id":"<id>"}
+func WithLegacyResponseBodies() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.legacyBodies = true
+		return nil
+	}
+}
+
+// writeID writes the id of a created or updated object and points the Location header to it
+func (endpoint *Endpoint) writeID(w http.ResponseWriter, r *http.Request, status int, id string) {
+	w.Header().Set("Location", endpoint.objectURL(r, id))
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, status, map[string]string{"id": id}, nil)
+		return
+	}
+	if endpoint.legacyBodies {
+		w.WriteHeader(status)
+		w.Write([]byte(id))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+// writeList writes the list of ids (or list items) as json array
+func (endpoint *Endpoint) writeList(w http.ResponseWriter, list interface{}, count int) {
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, list, map[string]interface{}{"count": count})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.Encode(list)
+}
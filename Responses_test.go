@@ -0,0 +1,38 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LegacyResponseBodies", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithLegacyResponseBodies()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond with the bare id", func() {
+		code, id := post(handler, "/", "foobar")
+		Expect(code).To(Equal(http.StatusCreated))
+		Expect(id).NotTo(BeEmpty())
+		code, data := get(handler, "/"+id)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("foobar"))
+		code, data = put(handler, "/key", "foobar")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(Equal("key"))
+	})
+})
@@ -0,0 +1,97 @@
+package crud
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// retryAfterStep is how much the Retry-After suggestion grows with each consecutive error of a client
+const retryAfterStep = 4 * time.Second
+
+// WithRetryAfterOnError adds a Retry-After header to all 500 and 503 responses, so clients back off instead of
+// retrying immediately. The suggestion grows linearly with the consecutive errors of the client ip,
+// 1s for the first, 5s for the second and so on up to max. A successful response resets the count of the client,
+// errors older than max are forgotten.
+func WithRetryAfterOnError(max time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.retryAfterMax = max
+		return nil
+	}
+}
+
+// retryAfter returns the Retry-After seconds for the given number of consecutive errors
+func (endpoint *Endpoint) retryAfter(errors int32) int {
+	suggestion := time.Second + time.Duration(errors-1)*retryAfterStep
+	if suggestion > endpoint.retryAfterMax {
+		suggestion = endpoint.retryAfterMax
+	}
+	seconds := int((suggestion + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// trackErrors makes the status writer suggest a Retry-After on errors and resets the client's errors on success
+func (endpoint *Endpoint) trackErrors(sw *statusWriter, r *http.Request) {
+	if endpoint.retryAfterMax <= 0 {
+		return
+	}
+	client := r.RemoteAddr
+	if ip := clientIP(r); ip != nil {
+		client = ip.String()
+	}
+	sw.beforeHeader = func(status int) {
+		switch {
+		case status == http.StatusInternalServerError || status == http.StatusServiceUnavailable:
+			errors := endpoint.countClientError(client, time.Now())
+			sw.Header().Set("Retry-After", strconv.Itoa(endpoint.retryAfter(errors)))
+		case status < 400:
+			endpoint.clientErrors.Delete(client)
+		}
+	}
+}
+
+// clientErrorCount are the consecutive errors of a client and the time of the last one
+type clientErrorCount struct {
+	mutex  sync.Mutex
+	errors int32
+	last   time.Time
+}
+
+// countClientError adds an error of the client and returns its consecutive errors. Errors older than
+// retryAfterMax don't count, and at most once per retryAfterMax the clients without recent errors are dropped.
+func (endpoint *Endpoint) countClientError(client string, now time.Time) int32 {
+	endpoint.sweepClientErrors(now)
+	value, _ := endpoint.clientErrors.LoadOrStore(client, &clientErrorCount{})
+	count := value.(*clientErrorCount)
+	count.mutex.Lock()
+	defer count.mutex.Unlock()
+	if now.Sub(count.last) > endpoint.retryAfterMax {
+		count.errors = 0
+	}
+	count.errors++
+	count.last = now
+	return count.errors
+}
+
+// sweepClientErrors drops the clients whose last error is older than retryAfterMax
+func (endpoint *Endpoint) sweepClientErrors(now time.Time) {
+	last := atomic.LoadInt64(&endpoint.clientErrorsSweep)
+	if now.Sub(time.Unix(0, last)) <= endpoint.retryAfterMax || !atomic.CompareAndSwapInt64(&endpoint.clientErrorsSweep, last, now.UnixNano()) {
+		return
+	}
+	endpoint.clientErrors.Range(func(client, value interface{}) bool {
+		count := value.(*clientErrorCount)
+		count.mutex.Lock()
+		stale := now.Sub(count.last) > endpoint.retryAfterMax
+		count.mutex.Unlock()
+		if stale {
+			endpoint.clientErrors.Delete(client)
+		}
+		return true
+	})
+}
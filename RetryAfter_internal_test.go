@@ -0,0 +1,21 @@
+package crud
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("client error tracking", func() {
+	It("should forget and drop errors older than the max retry after", func() {
+		endpoint := &Endpoint{retryAfterMax: time.Minute}
+		start := time.Now()
+		Expect(endpoint.countClientError("10.0.0.1", start)).To(Equal(int32(1)))
+		Expect(endpoint.countClientError("10.0.0.1", start.Add(time.Second))).To(Equal(int32(2)))
+		Expect(endpoint.countClientError("10.0.0.2", start.Add(2*time.Minute))).To(Equal(int32(1)))
+		_, ok := endpoint.clientErrors.Load("10.0.0.1")
+		Expect(ok).To(BeFalse())
+		Expect(endpoint.countClientError("10.0.0.1", start.Add(2*time.Minute))).To(Equal(int32(1)))
+	})
+})
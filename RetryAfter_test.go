@@ -0,0 +1,82 @@
+package crud_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failingReadStore fails all reads while failing is set
+type failingReadStore struct {
+	streamstore.Storage
+	failing bool
+}
+
+func (store *failingReadStore) GetReader(key string) (io.ReadCloser, error) {
+	if store.failing {
+		return nil, errors.New("backend unavailable")
+	}
+	return store.Storage.GetReader(key)
+}
+
+var _ = Describe("RetryAfter", func() {
+	var (
+		store    *failingReadStore
+		endpoint *Endpoint
+	)
+
+	BeforeEach(func() {
+		inner, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = &failingReadStore{Storage: inner}
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithRetryAfterOnError(7*time.Second)))
+		put(endpoint, "/key", `{}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	getFrom := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/key", nil)
+		req.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		endpoint.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	It("should suggest growing backoffs per client", func() {
+		store.failing = true
+		resp := getFrom("10.0.0.1:1234")
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Header().Get("Retry-After")).To(Equal("1"))
+		Expect(getFrom("10.0.0.1:1235").Header().Get("Retry-After")).To(Equal("5"))
+		Expect(getFrom("10.0.0.1:1236").Header().Get("Retry-After")).To(Equal("7"))
+		Expect(getFrom("10.0.0.2:1234").Header().Get("Retry-After")).To(Equal("1"))
+
+		store.failing = false
+		resp = getFrom("10.0.0.1:1237")
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Retry-After")).To(BeEmpty())
+		store.failing = true
+		Expect(getFrom("10.0.0.1:1238").Header().Get("Retry-After")).To(Equal("1"))
+	})
+
+	It("should not suggest a backoff on client errors", func() {
+		req := httptest.NewRequest("GET", "/missing", nil)
+		recorder := httptest.NewRecorder()
+		endpoint.ServeHTTP(recorder, req)
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		Expect(recorder.Header().Get("Retry-After")).To(BeEmpty())
+	})
+})
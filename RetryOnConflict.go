@@ -0,0 +1,19 @@
+package crud
+
+import "time"
+
+const conflictBaseBackoff = 10 * time.Millisecond
+
+// WithRetryOnConflict makes PATCH optimistic: the merged object is only written if the stored
+// object didn't change since it was read (compared by ETag). Otherwise the read-modify-write cycle
+// is retried up to maxRetries times with exponential back-off before 409 Conflict is returned.
+func WithRetryOnConflict(maxRetries int) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.retryOnConflict = maxRetries
+		return nil
+	}
+}
+
+func conflictBackoff(attempt int) time.Duration {
+	return conflictBaseBackoff << uint(attempt)
+}
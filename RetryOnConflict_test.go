@@ -0,0 +1,93 @@
+package crud_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// interferingStore simulates a concurrent writer: after the reads selected by interfere
+// it replaces the object by a version with an additional field
+type interferingStore struct {
+	streamstore.Storage
+	mutex     sync.Mutex
+	reads     int
+	interfere func(read int) bool
+}
+
+func (store *interferingStore) GetReader(id string) (io.ReadCloser, error) {
+	reader, err := store.Storage.GetReader(id)
+	if err != nil || strings.Count(id, "::") > 1 {
+		return reader, err
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+	store.mutex.Lock()
+	store.reads++
+	interfere := store.interfere(store.reads)
+	store.mutex.Unlock()
+	if interfere {
+		writer, _ := store.Storage.GetWriter(id)
+		writer.Write(bytes.Replace(content, []byte("{"), []byte(`{"concurrent":true,`), 1))
+		writer.Close()
+	}
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+var _ = Describe("RetryOnConflict", func() {
+	var store *interferingStore
+
+	BeforeEach(func() {
+		base, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = &interferingStore{Storage: base, interfere: func(int) bool { return false }}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should retry and merge into the concurrently modified object", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithRetryOnConflict(3)))
+		put(handler, "/key", `{"a":1}`)
+		store.interfere = func(read int) bool { return read == 1 }
+		code, data := patch(handler, "/key", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"concurrent":true,"a":1,"b":2}`))
+		store.interfere = func(int) bool { return false }
+		_, data = get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"concurrent":true,"a":1,"b":2}`))
+	})
+
+	It("should return 409 after too many conflicts", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithRetryOnConflict(2)))
+		put(handler, "/key", `{"a":1}`)
+		store.interfere = func(read int) bool { return read%2 == 1 }
+		code, _ := patch(handler, "/key", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusConflict))
+		Expect(store.reads).To(Equal(6))
+	})
+
+	It("should overwrite concurrent changes without the option", func() {
+		handler := NewEndpoint("test", store)
+		put(handler, "/key", `{"a":1}`)
+		store.interfere = func(read int) bool { return read == 1 }
+		code, data := patch(handler, "/key", `{"b":2}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`{"a":1,"b":2}`))
+	})
+})
@@ -0,0 +1,18 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithCustomRoutePrefix registers all routes below path, e.g. "/api/v1/items" serves "/api/v1/items/" and "/api/v1/items/{id}".
+// Use it if the endpoint is mounted without stripping the path, an empty path keeps the routes at the root.
+func WithCustomRoutePrefix(path string) Option {
+	return func(endpoint *Endpoint) error {
+		if path != "" && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("route prefix %q must start with /", path)
+		}
+		endpoint.routePrefix = strings.TrimSuffix(path, "/")
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RoutePrefix", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithCustomRoutePrefix("/api/v1/items/")))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve the routes below the prefix", func() {
+		resp := request(endpoint, "POST", "/api/v1/items/", `{"foo":"bar"}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusCreated))
+		Expect(resp.Header().Get("Location")).To(ContainSubstring("/api/v1/items/"))
+		code, _ := put(endpoint, "/api/v1/items/key", `{}`)
+		Expect(code).To(Equal(http.StatusOK))
+		code, body := get(endpoint, "/api/v1/items/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(ContainSubstring(`"key"`))
+	})
+
+	It("should not serve the routes at the root", func() {
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should look up handlers by their template", func() {
+		Expect(endpoint.Handler("GET", "/{id}")).NotTo(BeNil())
+	})
+
+	It("should reject relative prefixes", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithCustomRoutePrefix("api"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,118 @@
+package crud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// presignExpiry is how long the redirect urls of WithS3PresignedURLRedirect are valid
+const presignExpiry = 15 * time.Minute
+
+type s3Presigner struct {
+	bucket       string
+	region       string
+	host         string
+	expiry       time.Duration
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// WithS3PresignedURLRedirect answers GET requests of existing objects with 302 to a pre-signed S3 url for the object's
+// store key, so large objects are downloaded from S3 directly and the endpoint only authorizes the request.
+// The store has to write to the given bucket with the store keys as object keys, e.g. the minio store. All other
+// operations still go through the store. The credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// the optional AWS_SESSION_TOKEN, the urls are signed with AWS Signature Version 4 and valid for 15 minutes.
+// Fails if bucket, region or the credentials are missing.
+func WithS3PresignedURLRedirect(bucket, region string) Option {
+	return func(endpoint *Endpoint) error {
+		if bucket == "" || region == "" {
+			return errors.New("s3 redirect needs a bucket and a region")
+		}
+		presigner := &s3Presigner{
+			bucket:       bucket,
+			region:       region,
+			host:         bucket + ".s3." + region + ".amazonaws.com",
+			expiry:       presignExpiry,
+			accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if presigner.accessKey == "" || presigner.secretKey == "" {
+			return errors.New("s3 redirect needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		endpoint.s3Redirect = presigner
+		return nil
+	}
+}
+
+// redirectToS3 redirects a GET request to the pre-signed url of the object
+func (endpoint *Endpoint) redirectToS3(w http.ResponseWriter, r *http.Request, id string) {
+	if !endpoint.store.Has(endpoint.objectID(id)) {
+		if !endpoint.writeFallback(w, id) {
+			endpoint.writeError(w, http.StatusNotFound, "object not found")
+		}
+		return
+	}
+	http.Redirect(w, r, endpoint.s3Redirect.presign(endpoint.objectID(id), time.Now()), http.StatusFound)
+}
+
+// presign returns the url to GET the key signed at the given time, using the virtual-hosted style host of the bucket
+func (p *s3Presigner) presign(key string, now time.Time) string {
+	now = now.UTC()
+	date := now.Format("20060102")
+	scope := date + "/" + p.region + "/s3/aws4_request"
+	host := p.host
+	path := "/" + s3EscapePath(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", p.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(p.expiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if p.sessionToken != "" {
+		query.Set("X-Amz-Security-Token", p.sessionToken)
+	}
+	canonicalQuery := strings.Replace(query.Encode(), "+", "%20", -1)
+
+	canonicalRequest := strings.Join([]string{"GET", path, canonicalQuery, "host:" + host + "\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", now.Format("20060102T150405Z"), scope, hex.EncodeToString(hash[:])}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+p.secretKey), date)
+	signingKey = hmacSHA256(signingKey, p.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	return "https://" + host + path + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath percent-encodes an object key as required by signature version 4, keeping the slashes
+func s3EscapePath(key string) string {
+	var sb strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~', b == '/':
+			sb.WriteByte(b)
+		default:
+			sb.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{b})))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,106 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// schemaVersionField holds the schema version of stored json objects, objects without it have version 0
+const schemaVersionField = "_schema_version"
+
+// Migration upgrades stored json objects from one schema version to a later one
+type Migration struct {
+	From, To int
+	Migrate  func(data []byte) ([]byte, error)
+}
+
+// WithSchemaEvolution upgrades json objects returned by GET to the latest schema version.
+// Starting at the _schema_version of the stored object, the migration whose From matches the version is applied
+// until none matches, then _schema_version is set to the reached version. The stored object isn't changed
+// unless WithWritebackMigrations is given. Fails if a migration doesn't go forward or two start at the same version.
+func WithSchemaEvolution(migrations []Migration) Option {
+	return func(endpoint *Endpoint) error {
+		sorted := append([]Migration(nil), migrations...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].From < sorted[j].From })
+		for i, migration := range sorted {
+			if migration.Migrate == nil || migration.To <= migration.From {
+				return fmt.Errorf("invalid migration from version %v to %v", migration.From, migration.To)
+			}
+			if i > 0 && sorted[i-1].From == migration.From {
+				return fmt.Errorf("multiple migrations from version %v", migration.From)
+			}
+		}
+		endpoint.migrations = sorted
+		return nil
+	}
+}
+
+// WithWritebackMigrations stores objects migrated by WithSchemaEvolution, unless they were changed in between
+func WithWritebackMigrations() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.writeMigrations = true
+		return nil
+	}
+}
+
+// migrate applies the migrations to a stored object and reports whether it changed.
+// Content which isn't a json object is returned unchanged.
+func (endpoint *Endpoint) migrate(content []byte) ([]byte, bool, error) {
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(content, &header); err != nil {
+		return content, false, nil
+	}
+	version := 0
+	if raw, ok := header[schemaVersionField]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, false, fmt.Errorf("invalid %v: %v", schemaVersionField, err)
+		}
+	}
+	migrated := false
+	for _, migration := range endpoint.migrations {
+		if migration.From != version {
+			continue
+		}
+		next, err := migration.Migrate(content)
+		if err != nil {
+			return nil, false, fmt.Errorf("migration from version %v to %v failed: %v", migration.From, migration.To, err)
+		}
+		content, version, migrated = next, migration.To, true
+	}
+	if !migrated {
+		return content, false, nil
+	}
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(content, &obj); err != nil {
+		return nil, false, fmt.Errorf("migration to version %v returned no json object: %v", version, err)
+	}
+	obj[schemaVersionField] = version
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(obj); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// migrateStored migrates the content of a stored object and writes it back if configured,
+// the ETag header then refers to the written content
+func (endpoint *Endpoint) migrateStored(w http.ResponseWriter, objectID string, content []byte) ([]byte, error) {
+	migrated, changed, err := endpoint.migrate(content)
+	if err != nil || !changed || !endpoint.writeMigrations {
+		return migrated, err
+	}
+	err = endpoint.writeIfMatch(objectID, computeETag(content), migrated)
+	if err == errPreconditionFailed {
+		return migrated, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.conflictDetection {
+		w.Header().Set("ETag", computeETag(migrated))
+	}
+	return migrated, nil
+}
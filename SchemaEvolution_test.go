@@ -0,0 +1,75 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SchemaEvolution", func() {
+	var store streamstore.Storage
+
+	// renames "name" to "full_name"
+	rename := Migration{From: 0, To: 1, Migrate: func(data []byte) ([]byte, error) {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		obj["full_name"] = obj["name"]
+		delete(obj, "name")
+		return json.Marshal(obj)
+	}}
+	// adds "active"
+	activate := Migration{From: 1, To: 2, Migrate: func(data []byte) ([]byte, error) {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+		obj["active"] = true
+		return json.Marshal(obj)
+	}}
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		put(NewEndpoint("test", store), "/old", `{"name":"alice"}`)
+		put(NewEndpoint("test", store), "/newer", `{"full_name":"bob","_schema_version":1}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should migrate objects on read", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithSchemaEvolution([]Migration{activate, rename})))
+		code, body := get(endpoint, "/old")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"full_name":"alice","active":true,"_schema_version":2}`))
+		_, body = get(endpoint, "/newer")
+		Expect(body).To(MatchJSON(`{"full_name":"bob","active":true,"_schema_version":2}`))
+		_, body = get(NewEndpoint("test", store), "/old")
+		Expect(body).To(MatchJSON(`{"name":"alice"}`))
+	})
+
+	It("should write back migrated objects on request", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithSchemaEvolution([]Migration{rename, activate}), WithWritebackMigrations()))
+		get(endpoint, "/old")
+		_, body := get(NewEndpoint("test", store), "/old")
+		Expect(body).To(MatchJSON(`{"full_name":"alice","active":true,"_schema_version":2}`))
+	})
+
+	It("should reject invalid migrations", func() {
+		_, err := NewEndpointWithOptions("test", store, WithSchemaEvolution([]Migration{{From: 1, To: 1, Migrate: rename.Migrate}}))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithSchemaEvolution([]Migration{rename, {From: 0, To: 2, Migrate: rename.Migrate}}))
+		Expect(err).To(HaveOccurred())
+	})
+})
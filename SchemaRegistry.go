@@ -0,0 +1,214 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// SchemaRegistry maps content types to JSON schemas.
+// It supports the schema keywords type, properties, required, additionalProperties, items, enum,
+// minimum, maximum, minLength and maxLength.
+type SchemaRegistry struct {
+	mutex   sync.RWMutex
+	schemas map[string]*jsonSchema
+}
+
+// NewSchemaRegistry constructs an empty schema registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string]*jsonSchema)}
+}
+
+// Register sets the schema of a content type, parameters of the content type are ignored.
+// It fails if the schema is not valid json.
+func (reg *SchemaRegistry) Register(contentType string, schema []byte) error {
+	parsed := new(jsonSchema)
+	if err := json.Unmarshal(schema, parsed); err != nil {
+		return fmt.Errorf("invalid schema for %v: %v", contentType, err)
+	}
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	reg.schemas[mediaType(contentType)] = parsed
+	return nil
+}
+
+// Validate checks data against the schema of a content type.
+// Content types without a registered schema are always valid.
+func (reg *SchemaRegistry) Validate(contentType string, data []byte) error {
+	reg.mutex.RLock()
+	schema, ok := reg.schemas[mediaType(contentType)]
+	reg.mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &unmarshalError{err: err}
+	}
+	return schema.validate("", value)
+}
+
+// WithObjectSchemaRegistry validates written objects against the schema registered for their content type.
+// The content type is the request's Content-Type, otherwise the stored or default content type,
+// otherwise application/json. Invalid objects get 422.
+func WithObjectSchemaRegistry(reg *SchemaRegistry) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.schemas = reg
+		return nil
+	}
+}
+
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return parsed
+}
+
+// schemaContentType returns the content type whose schema applies to a write
+func (endpoint *Endpoint) schemaContentType(r *http.Request, id string) string {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && r.Method != "PATCH" {
+		return contentType
+	}
+	if contentType, err := endpoint.readSidecar(id, sidecarContentType); err == nil && len(contentType) > 0 {
+		return string(contentType)
+	}
+	if endpoint.defaultContentType != "" {
+		return endpoint.defaultContentType
+	}
+	return "application/json"
+}
+
+// checkSchema validates the request body and writes 422 and returns false if it is invalid
+func (endpoint *Endpoint) checkSchema(w http.ResponseWriter, r *http.Request, id string) bool {
+	if endpoint.schemas == nil {
+		return true
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return endpoint.checkSchemaData(w, r, id, data)
+}
+
+// checkSchemaData validates data and writes 422 and returns false if it is invalid
+func (endpoint *Endpoint) checkSchemaData(w http.ResponseWriter, r *http.Request, id string, data []byte) bool {
+	if endpoint.schemas == nil {
+		return true
+	}
+	err := endpoint.schemas.Validate(endpoint.schemaContentType(r, id), data)
+	if _, ok := err.(*unmarshalError); ok {
+		endpoint.writeUnmarshalError(w, err)
+		return false
+	}
+	if err != nil {
+		endpoint.writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return true
+}
+
+// jsonSchema is the supported subset of JSON schema
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+}
+
+func (schema *jsonSchema) validate(path string, value interface{}) error {
+	if path == "" {
+		path = "object"
+	}
+	if schema.Type != "" && !hasJSONType(value, schema.Type) {
+		return fmt.Errorf("%v must be of type %v", path, schema.Type)
+	}
+	if len(schema.Enum) > 0 && !containsJSONValue(schema.Enum, value) {
+		return fmt.Errorf("%v must be one of %v", path, schema.Enum)
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%v misses required field %v", path, name)
+			}
+		}
+		for name, field := range v {
+			property, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					return fmt.Errorf("%v must not have field %v", path, name)
+				}
+				continue
+			}
+			if err := property.validate(path+"."+name, field); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range v {
+				if err := schema.Items.validate(fmt.Sprintf("%v[%v]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case float64:
+		if schema.Minimum != nil && v < *schema.Minimum {
+			return fmt.Errorf("%v must be at least %v", path, *schema.Minimum)
+		}
+		if schema.Maximum != nil && v > *schema.Maximum {
+			return fmt.Errorf("%v must be at most %v", path, *schema.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return fmt.Errorf("%v must have at least %v characters", path, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return fmt.Errorf("%v must have at most %v characters", path, *schema.MaxLength)
+		}
+	}
+	return nil
+}
+
+func hasJSONType(value interface{}, typ string) bool {
+	switch v := value.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == float64(int64(v)))
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
+
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	encoded, _ := json.Marshal(value)
+	for _, candidate := range values {
+		if c, _ := json.Marshal(candidate); bytes.Equal(c, encoded) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SchemaRegistry", func() {
+	var handler http.Handler
+	userJSON := http.Header{"Content-Type": {"application/vnd.user+json"}}
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		reg := NewSchemaRegistry()
+		Expect(reg.Register("application/vnd.user+json", []byte(`{
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string", "minLength": 1}, "age": {"type": "integer", "minimum": 0}}
+		}`))).To(Succeed())
+		Expect(reg.Register("application/json", []byte(`{"type": "object"}`))).To(Succeed())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithObjectSchemaRegistry(reg)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should validate objects against the schema of their content type", func() {
+		Expect(request(handler, "PUT", "/alice", `{"name":"alice","age":30}`, userJSON).Code).To(Equal(http.StatusOK))
+		Expect(request(handler, "PUT", "/bob", `{"age":30}`, userJSON).Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(request(handler, "PUT", "/bob", `{"name":"bob","age":-1}`, userJSON).Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(request(handler, "PUT", "/bob", `{"name":"bob","age":1.5}`, userJSON).Code).To(Equal(http.StatusUnprocessableEntity))
+	})
+
+	It("should use application/json without content type", func() {
+		code, _ := put(handler, "/list", `[1,2]`)
+		Expect(code).To(Equal(http.StatusUnprocessableEntity))
+		code, _ = put(handler, "/object", `{"a":1}`)
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should ignore content types without schema", func() {
+		Expect(request(handler, "PUT", "/key", "foobar", http.Header{"Content-Type": {"text/plain"}}).Code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid schemas", func() {
+		Expect(NewSchemaRegistry().Register("application/json", []byte("{"))).NotTo(Succeed())
+	})
+})
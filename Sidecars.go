@@ -0,0 +1,126 @@
+package crud
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Sidecars are small objects stored next to an object under the key <prefix>::<id>::<name>,
+// where "::" is the configured delimiter.
+const (
+	sidecarContentType = "content-type"
+	sidecarSize        = "size"
+	sidecarTags        = "tags"
+	sidecarMeta        = "meta"
+	// sidecarUpload suffixes the keys writes are staged under, see stageWrites
+	sidecarUpload = "upload"
+)
+
+var sidecarNames = []string{
+	sidecarContentType,
+	sidecarSize,
+	sidecarTags,
+	sidecarMeta,
+}
+
+// objectID returns the store key of an object
+func (endpoint *Endpoint) objectID(id string) string {
+	return endpoint.storePrefix + endpoint.delimiter + id
+}
+
+// sidecarID returns the store key of a sidecar of an object
+func (endpoint *Endpoint) sidecarID(id, name string) string {
+	return endpoint.objectID(id) + endpoint.delimiter + name
+}
+
+// isSidecar returns whether a stripped key belongs to a sidecar
+func isSidecar(key, delimiter string) bool {
+	for _, name := range sidecarNames {
+		if strings.HasSuffix(key, delimiter+name) {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, delimiter+sidecarUpload)
+}
+
+// listIDs returns the ids of all objects of this endpoint, without the prefix and without sidecars
+func (endpoint *Endpoint) listIDs() ([]string, error) {
+	keys, err := endpoint.store.List(endpoint.storePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.idsFromKeys(keys), nil
+}
+
+// idsFromKeys strips the prefix from the store keys and skips the sidecars
+func (endpoint *Endpoint) idsFromKeys(keys []string) []string {
+	prefix := endpoint.objectID("")
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		id := strings.TrimPrefix(key, prefix)
+		if isSidecar(id, endpoint.delimiter) || endpoint.inChildSpace(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (endpoint *Endpoint) readSidecar(id, name string) ([]byte, error) {
+	reader, err := endpoint.store.GetReader(endpoint.sidecarID(id, name))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+func (endpoint *Endpoint) writeSidecar(id, name string, data []byte) error {
+	writer, err := endpoint.store.GetWriter(endpoint.sidecarID(id, name))
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// deleteSidecars removes all existing sidecars of an object
+func (endpoint *Endpoint) deleteSidecars(id string) error {
+	for _, name := range sidecarNames {
+		sidecarID := endpoint.sidecarID(id, name)
+		if !endpoint.store.Has(sidecarID) {
+			continue
+		}
+		if err := endpoint.store.Delete(sidecarID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveSidecars writes the sidecars of the enabled features after an object was written
+func (endpoint *Endpoint) saveSidecars(r *http.Request, id string, size int64) error {
+	if endpoint.binary {
+		if err := endpoint.saveBinarySidecars(r, id, size); err != nil {
+			return err
+		}
+	}
+	if tags := r.Header.Get("X-Tags"); tags != "" {
+		if err := endpoint.saveTags(id, parseTags(tags)); err != nil {
+			return err
+		}
+	}
+	if endpoint.metadata {
+		if err := endpoint.touchMetadata(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
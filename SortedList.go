@@ -0,0 +1,157 @@
+package crud
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SortOrder specifies the order of sorted list responses
+type SortOrder int
+
+const (
+	// Ascending sorts keys in lexicographic order
+	Ascending SortOrder = iota
+	// Descending sorts keys in reverse lexicographic order
+	Descending
+)
+
+// WithSortedList sorts the keys returned by list requests.
+// The default order is Ascending.
+func WithSortedList(order ...SortOrder) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.sortOrder = Ascending
+		if len(order) > 0 {
+			endpoint.sortOrder = order[0]
+		}
+		endpoint.sortList = true
+		return nil
+	}
+}
+
+func (endpoint *Endpoint) sortKeys(keys []string) {
+	if !endpoint.sortList {
+		return
+	}
+	if endpoint.sortOrder == Descending {
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+		return
+	}
+	sort.Strings(keys)
+}
+
+// fieldSortEntry is an id with the value of the field it is sorted by
+type fieldSortEntry struct {
+	id    string
+	value interface{}
+	found bool
+}
+
+// fieldSortPool recycles the entry slices of field sorts to reduce allocations of repeated list requests.
+// It holds pointers, so putting a slice back doesn't allocate.
+var fieldSortPool = sync.Pool{
+	New: func() interface{} {
+		entries := make([]fieldSortEntry, 0, 64)
+		return &entries
+	},
+}
+
+// sortByField sorts keys by a top level field of the stored json objects, as requested by ?sort=<field>:<asc|desc>.
+// Every object has to be read, so this gets slow for large endpoints. Objects without the field come first,
+// values of different json types are ordered null < bool < number < string, ties are ordered by id.
+func (endpoint *Endpoint) sortByField(keys []string, param string) error {
+	field, direction := param, "asc"
+	if i := strings.LastIndex(param, ":"); i >= 0 {
+		field, direction = param[:i], param[i+1:]
+	}
+	if field == "" || (direction != "asc" && direction != "desc") {
+		return errors.New("sort must be <field>:asc or <field>:desc")
+	}
+	if endpoint.binary {
+		return errors.New("sort by field needs json objects")
+	}
+	pooled := fieldSortPool.Get().(*[]fieldSortEntry)
+	entries := (*pooled)[:0]
+	defer func() {
+		for i := range entries {
+			entries[i] = fieldSortEntry{}
+		}
+		*pooled = entries[:0]
+		fieldSortPool.Put(pooled)
+	}()
+	jsonObjects := 0
+	for _, id := range keys {
+		entry := fieldSortEntry{id: id}
+		if obj, _, err := endpoint.readJSONObject(endpoint.objectID(id)); err == nil {
+			jsonObjects++
+			entry.value, entry.found = obj[field]
+		}
+		entries = append(entries, entry)
+	}
+	if len(keys) > 0 && jsonObjects == 0 {
+		return errors.New("sort by field needs json objects")
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if c := compareFieldValues(entries[i], entries[j]); c != 0 {
+			return (c < 0) == (direction == "asc")
+		}
+		return entries[i].id < entries[j].id
+	})
+	for i, entry := range entries {
+		keys[i] = entry.id
+	}
+	return nil
+}
+
+func fieldValueRank(entry fieldSortEntry) int {
+	if !entry.found {
+		return 0
+	}
+	switch entry.value.(type) {
+	case nil:
+		return 1
+	case bool:
+		return 2
+	case float64:
+		return 3
+	case string:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compareFieldValues returns -1, 0 or 1 like strings.Compare
+func compareFieldValues(a, b fieldSortEntry) int {
+	rankA, rankB := fieldValueRank(a), fieldValueRank(b)
+	if rankA != rankB {
+		if rankA < rankB {
+			return -1
+		}
+		return 1
+	}
+	switch valueA := a.value.(type) {
+	case bool:
+		valueB := b.value.(bool)
+		if valueA == valueB {
+			return 0
+		}
+		if !valueA {
+			return -1
+		}
+		return 1
+	case float64:
+		valueB := b.value.(float64)
+		if valueA < valueB {
+			return -1
+		}
+		if valueA > valueB {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(valueA, b.value.(string))
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SortedList", func() {
+	var (
+		store streamstore.Storage
+		ids   = []string{"f", "c", "j", "a", "h", "b", "e", "i", "d", "g"}
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	list := func(handler http.Handler) []string {
+		for _, id := range ids {
+			put(handler, "/"+id, "foobar")
+		}
+		code, data := get(handler, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		keys := []string{}
+		Expect(json.Unmarshal([]byte(data), &keys)).To(Succeed())
+		Expect(keys).To(HaveLen(len(ids)))
+		return keys
+	}
+
+	It("should sort keys ascending by default", func() {
+		keys := list(mustEndpoint(NewEndpointWithOptions("test", store, WithSortedList())))
+		Expect(sort.StringsAreSorted(keys)).To(BeTrue())
+	})
+
+	It("should sort keys descending", func() {
+		keys := list(mustEndpoint(NewEndpointWithOptions("test", store, WithSortedList(Descending))))
+		Expect(sort.IsSorted(sort.Reverse(sort.StringSlice(keys)))).To(BeTrue())
+	})
+
+	It("should sort by a json field", func() {
+		handler := NewEndpoint("test", store)
+		put(handler, "/a", `{"age":30}`)
+		put(handler, "/b", `{"age":20}`)
+		put(handler, "/c", `{"name":"no age"}`)
+		put(handler, "/d", `{"age":25}`)
+		sorted := func(query string) []string {
+			code, data := get(handler, "/?sort="+query)
+			Expect(code).To(Equal(http.StatusOK))
+			keys := []string{}
+			Expect(json.Unmarshal([]byte(data), &keys)).To(Succeed())
+			return keys
+		}
+		Expect(sorted("age:asc")).To(Equal([]string{"c", "b", "d", "a"}))
+		Expect(sorted("age:desc")).To(Equal([]string{"a", "d", "b", "c"}))
+	})
+
+	It("should reject field sorts of non-json objects", func() {
+		handler := NewEndpoint("test", store)
+		put(handler, "/a", "foobar")
+		code, _ := get(handler, "/?sort=age:asc")
+		Expect(code).To(Equal(http.StatusBadRequest))
+		code, _ = get(handler, "/?sort=age:up")
+		Expect(code).To(Equal(http.StatusBadRequest))
+	})
+})
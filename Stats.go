@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithStatsEndpoint serves GET <path>, e.g. "/stats", with the request statistics of the endpoint as json:
+// {"ops":{"get":N,...},"errors":{"get":N,...},"avg_latency_ms":{"get":N,...}}. Errors count responses with
+// status >= 500, latencies are averaged since the start. The route takes precedence over GET /{id}.
+// The statistics aren't protected, restrict access with a middleware if needed.
+func WithStatsEndpoint(path string) Option {
+	return func(endpoint *Endpoint) error {
+		if !strings.HasPrefix(path, "/") || len(path) < 2 {
+			return fmt.Errorf("invalid stats path %q", path)
+		}
+		endpoint.statsPath = path
+		return nil
+	}
+}
+
+type stats struct {
+	Ops          map[string]int64   `json:"ops"`
+	Errors       map[string]int64   `json:"errors"`
+	AvgLatencyMs map[string]float64 `json:"avg_latency_ms"`
+}
+
+func (endpoint *Endpoint) handleStats(w http.ResponseWriter, r *http.Request) {
+	snapshot := endpoint.Metrics()
+	result := stats{
+		Ops:          snapshot.OperationCounts,
+		Errors:       snapshot.OperationErrors,
+		AvgLatencyMs: snapshot.OperationLatencyMs,
+	}
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, result, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
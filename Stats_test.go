@@ -0,0 +1,58 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stats", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithStatsEndpoint("/stats")))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve the request statistics", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		get(endpoint, "/key")
+		get(endpoint, "/key")
+		code, data := get(endpoint, "/stats")
+		Expect(code).To(Equal(http.StatusOK))
+		result := struct {
+			Ops          map[string]int64   `json:"ops"`
+			Errors       map[string]int64   `json:"errors"`
+			AvgLatencyMs map[string]float64 `json:"avg_latency_ms"`
+		}{}
+		Expect(json.Unmarshal([]byte(data), &result)).To(Succeed())
+		Expect(result.Ops).To(Equal(map[string]int64{"put": 1, "get": 2}))
+		Expect(result.Errors).To(Equal(map[string]int64{"put": 0, "get": 0}))
+		Expect(result.AvgLatencyMs).To(HaveKey("get"))
+	})
+
+	It("should be disabled by default", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		code, _ := get(NewEndpoint("test", store), "/stats")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should reject invalid paths", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithStatsEndpoint("stats"))
+		Expect(err).To(HaveOccurred())
+	})
+})
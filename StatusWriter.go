@@ -0,0 +1,48 @@
+package crud
+
+import "net/http"
+
+// statusWriter captures the status code and the number of bytes written to a http.ResponseWriter
+type statusWriter struct {
+	http.ResponseWriter
+	status       int
+	written      int64
+	wroteHeader  bool
+	capture      *captureBuffer
+	beforeHeader func(status int)
+}
+
+func newStatusWriter(w http.ResponseWriter) *statusWriter {
+	return &statusWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader records the status code and passes it to the underlying writer
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.status = status
+		sw.wroteHeader = true
+		if sw.beforeHeader != nil {
+			sw.beforeHeader(status)
+		}
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the written bytes and passes them to the underlying writer
+func (sw *statusWriter) Write(data []byte) (int, error) {
+	if !sw.wroteHeader && sw.beforeHeader != nil {
+		sw.beforeHeader(http.StatusOK)
+	}
+	sw.wroteHeader = true
+	n, err := sw.ResponseWriter.Write(data)
+	sw.written += int64(n)
+	sw.capture.capture(data[:n])
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer supports it
+func (sw *statusWriter) Flush() {
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,126 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StorageIterator is an optional interface of stores which can list keys one by one instead of returning all at once.
+// If the store of an endpoint implements it, plain list requests are streamed as json array while the store iterates.
+// The max list size applies to streamed lists too, but as they are sent before the store was iterated completely,
+// truncation is signaled by the trailer X-Truncated: true and X-Total-Count is missing.
+type StorageIterator interface {
+	// Iterate calls fn for each key with the given prefix and stops at the first error returned by fn
+	Iterate(prefix string, fn func(key string) error) error
+}
+
+// listQueryParams are the list parameters which need all ids at once, they disable streaming
+var listQueryParams = []string{"ids", "tag", "sort", "created_after", "created_before", "limit", "offset", "page", "page_size", "include_tags", "changed_since", "format"}
+
+// storageIterator returns the iterator of the store, if it has one
+func (endpoint *Endpoint) storageIterator() (StorageIterator, bool) {
+	store := endpoint.store
+	if dryRun, ok := store.(*dryRunStorage); ok {
+		store = dryRun.Storage
+	}
+	if timed, ok := store.(*timedStorage); ok {
+		store = timed.Storage
+	}
+	if cached, ok := store.(*cachedStorage); ok {
+		store = cached.Storage
+	}
+	if retrying, ok := store.(*retryingStorage); ok {
+		store = retrying.Storage
+	}
+	iterator, ok := store.(StorageIterator)
+	return iterator, ok
+}
+
+// canIterateList returns whether a list request can be answered while iterating the store.
+// This is not possible if the list is sorted, paginated, enveloped or needs more than the bare ids.
+func (endpoint *Endpoint) canIterateList(r *http.Request) bool {
+	if _, ok := endpoint.storageIterator(); !ok {
+		return false
+	}
+	if endpoint.sortList || endpoint.listFilter != nil || endpoint.listResponse != nil || endpoint.envelope || endpoint.binary || endpoint.streamingList {
+		return false
+	}
+	query := r.URL.Query()
+	for _, param := range listQueryParams {
+		if query.Get(param) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// errListTruncated stops the iteration once the max list size is exceeded
+var errListTruncated = errors.New("list truncated")
+
+// writeIteratedList writes the ids as json array while iterating the store, flushing after every 100 ids.
+// It stops after the max list size, setting the X-Truncated trailer if more ids remain.
+// Errors before the first id get a 500 response, later errors abort the response leaving the array unterminated.
+func (endpoint *Endpoint) writeIteratedList(w http.ResponseWriter, r *http.Request) {
+	iterator, _ := endpoint.storageIterator()
+	flusher, _ := w.(http.Flusher)
+	prefix := endpoint.objectID("")
+	count := 0
+	truncated := false
+	span := endpoint.storeSpan(r, "Iterate", "")
+	defer span.End()
+	start := time.Now()
+	err := iterator.Iterate(endpoint.storePrefix, func(key string) error {
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		id := strings.TrimPrefix(key, prefix)
+		if isSidecar(id, endpoint.delimiter) || endpoint.inChildSpace(id) || !endpoint.allowedID(id) || !endpoint.mayAccess(r, OperationList, id) || endpoint.isExpired(id) {
+			return nil
+		}
+		if endpoint.maxListSize > 0 && count == endpoint.maxListSize {
+			truncated = true
+			return errListTruncated
+		}
+		entry, err := json.Marshal(endpoint.listedID(id))
+		if err != nil {
+			return err
+		}
+		separator := ","
+		if count == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			if endpoint.maxListSize > 0 {
+				w.Header().Set("Trailer", "X-Truncated")
+			}
+			separator = "["
+		}
+		if _, err = w.Write(append([]byte(separator), entry...)); err != nil {
+			return err
+		}
+		count++
+		if flusher != nil && count%streamingFlushInterval == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if timed, ok := endpoint.store.(*timedStorage); ok {
+		timed.record("store.list", start)
+	}
+	if err != nil && err != errListTruncated {
+		if count == 0 {
+			endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if count == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]\n"))
+		return
+	}
+	w.Write([]byte("]\n"))
+	if truncated {
+		w.Header().Set("X-Truncated", "true")
+	}
+}
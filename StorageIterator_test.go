@@ -0,0 +1,100 @@
+package crud_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// iteratingStore can only be listed by iterating
+type iteratingStore struct {
+	streamstore.Storage
+}
+
+func (store iteratingStore) List(prefix string) ([]string, error) {
+	return nil, errors.New("list not supported")
+}
+
+func (store iteratingStore) Iterate(prefix string, fn func(key string) error) error {
+	keys, err := store.Storage.List(prefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ = Describe("StorageIterator", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", iteratingStore{store}, WithMaxListSize(0)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should stream the ids while iterating", func() {
+		put(endpoint, "/a", `{}`)
+		put(endpoint, "/b", `{}`)
+		resp := request(endpoint, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(resp.Body.String()).To(MatchJSON(`["a","b"]`))
+	})
+
+	It("should stream empty lists", func() {
+		code, body := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`[]`))
+	})
+
+	It("should stream with the default options", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", iteratingStore{store})
+		put(endpoint, "/a", `{}`)
+		code, body := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`["a"]`))
+	})
+
+	It("should stop after the max list size", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", iteratingStore{store}, WithMaxListSize(2)))
+		put(endpoint, "/a", `{}`)
+		put(endpoint, "/b", `{}`)
+		resp := request(endpoint, "GET", "/", "", nil).Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		Expect(body).To(MatchJSON(`["a","b"]`))
+		Expect(resp.Trailer.Get("X-Truncated")).To(BeEmpty())
+
+		put(endpoint, "/c", `{}`)
+		resp = request(endpoint, "GET", "/", "", nil).Result()
+		body, _ = ioutil.ReadAll(resp.Body)
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`["a","b"]`))
+		Expect(resp.Trailer.Get("X-Truncated")).To(Equal("true"))
+	})
+
+	It("should fall back to listing if the query needs all ids", func() {
+		code, _ := get(endpoint, "/?limit=1")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+	})
+})
@@ -0,0 +1,114 @@
+package crud
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/trusch/streamstore"
+)
+
+// BackoffPolicy decides whether and after which wait a failed store call is retried
+type BackoffPolicy interface {
+	Next() (wait time.Duration, ok bool)
+}
+
+// WithStoreBackoff retries failing store calls as long as policy.Next allows, waiting the returned duration in between.
+// Opening readers and writers, List and Delete are retried, "not found" errors and the transfer of an opened stream
+// aren't. If the policy has a method Clone() BackoffPolicy, like ExponentialBackoff, each store call starts with
+// a fresh clone, other policies are shared by all calls.
+func WithStoreBackoff(policy BackoffPolicy) Option {
+	return func(endpoint *Endpoint) error {
+		if policy == nil {
+			return errors.New("no backoff policy given")
+		}
+		endpoint.storeBackoff = policy
+		return nil
+	}
+}
+
+// ExponentialBackoff waits base, 2*base, 4*base and so on, at most max, for up to maxRetries retries
+func ExponentialBackoff(base, max time.Duration, maxRetries int) BackoffPolicy {
+	return &exponentialBackoff{base: base, max: max, maxRetries: maxRetries}
+}
+
+type exponentialBackoff struct {
+	base, max  time.Duration
+	maxRetries int
+	retries    int
+}
+
+func (policy *exponentialBackoff) Next() (time.Duration, bool) {
+	if policy.retries >= policy.maxRetries {
+		return 0, false
+	}
+	wait := policy.base << uint(policy.retries)
+	if wait > policy.max || wait < policy.base {
+		wait = policy.max
+	}
+	policy.retries++
+	return wait, true
+}
+
+func (policy *exponentialBackoff) Clone() BackoffPolicy {
+	return &exponentialBackoff{base: policy.base, max: policy.max, maxRetries: policy.maxRetries}
+}
+
+// retryingStorage retries the failing calls of the wrapped store
+type retryingStorage struct {
+	streamstore.Storage
+	policy BackoffPolicy
+}
+
+func (store *retryingStorage) String() string {
+	return "retrying " + describeStore(store.Storage)
+}
+
+// retry calls fn until it succeeds, fails with a not found error or the policy gives up
+func (store *retryingStorage) retry(fn func() error) error {
+	policy := store.policy
+	if cloner, ok := policy.(interface{ Clone() BackoffPolicy }); ok {
+		policy = cloner.Clone()
+	}
+	for {
+		err := fn()
+		if err == nil || isNotFound(err) {
+			return err
+		}
+		wait, ok := policy.Next()
+		if !ok {
+			return err
+		}
+		time.Sleep(wait)
+	}
+}
+
+func (store *retryingStorage) GetReader(id string) (reader io.ReadCloser, err error) {
+	err = store.retry(func() error {
+		reader, err = store.Storage.GetReader(id)
+		return err
+	})
+	return reader, err
+}
+
+func (store *retryingStorage) GetWriter(id string) (writer io.WriteCloser, err error) {
+	err = store.retry(func() error {
+		writer, err = store.Storage.GetWriter(id)
+		return err
+	})
+	return writer, err
+}
+
+func (store *retryingStorage) List(prefix string) (keys []string, err error) {
+	err = store.retry(func() error {
+		keys, err = store.Storage.List(prefix)
+		return err
+	})
+	return keys, err
+}
+
+func (store *retryingStorage) Delete(id string) error {
+	return store.retry(func() error {
+		return store.Storage.Delete(id)
+	})
+}
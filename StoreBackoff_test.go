@@ -0,0 +1,86 @@
+package crud_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// flakyListStore fails the first failures List calls
+type flakyListStore struct {
+	streamstore.Storage
+	failures int
+	calls    int
+}
+
+func (store *flakyListStore) List(prefix string) ([]string, error) {
+	store.calls++
+	if store.calls <= store.failures {
+		return nil, errors.New("connection reset")
+	}
+	return store.Storage.List(prefix)
+}
+
+var _ = Describe("StoreBackoff", func() {
+	var store *flakyListStore
+
+	BeforeEach(func() {
+		base, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = &flakyListStore{Storage: base, failures: 2}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should retry transient store errors", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreBackoff(ExponentialBackoff(time.Millisecond, 10*time.Millisecond, 3))))
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(store.calls).To(Equal(3))
+	})
+
+	It("should give up after the last retry", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreBackoff(ExponentialBackoff(time.Millisecond, 10*time.Millisecond, 1))))
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusInternalServerError))
+		Expect(store.calls).To(Equal(2))
+	})
+
+	It("should start every store call with a fresh policy", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreBackoff(ExponentialBackoff(time.Millisecond, 10*time.Millisecond, 2))))
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		store.calls, store.failures = 0, 2
+		code, _ = get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should not retry missing objects", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreBackoff(ExponentialBackoff(time.Hour, time.Hour, 3))))
+		code, _ := get(endpoint, "/missing")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should double the wait up to the maximum", func() {
+		policy := ExponentialBackoff(time.Second, 3*time.Second, 3)
+		waits := []time.Duration{}
+		for {
+			wait, ok := policy.Next()
+			if !ok {
+				break
+			}
+			waits = append(waits, wait)
+		}
+		Expect(waits).To(Equal([]time.Duration{time.Second, 2 * time.Second, 3 * time.Second}))
+	})
+})
@@ -0,0 +1,190 @@
+package crud
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/trusch/streamstore"
+)
+
+// CacheStore caches store content by key
+type CacheStore interface {
+	// Get returns the cached content and whether it was found and is not expired
+	Get(key string) ([]byte, bool)
+	// Set caches content for ttl, a ttl <= 0 caches without expiry
+	Set(key string, val []byte, ttl time.Duration)
+	// Delete removes a key from the cache
+	Delete(key string)
+}
+
+// WithStoreCache caches reads from the store for ttl. Writes and deletes through the endpoint invalidate the cached keys,
+// and reads running while a write finished don't fill the cache. Changes made to the store by others are visible after the ttl. Objects are cached completely, so
+// combine it with WithObjectSizeLimit if objects can be large.
+func WithStoreCache(cache CacheStore, ttl time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if cache == nil {
+			return errors.New("no cache given")
+		}
+		endpoint.cache = cache
+		endpoint.cacheTTL = ttl
+		return nil
+	}
+}
+
+// cachedStorage is a read-through cache in front of a store.
+// version counts the finished writes and deletes, a read only fills the cache if none finished while it ran,
+// otherwise it might cache content which was replaced in the meantime.
+type cachedStorage struct {
+	streamstore.Storage
+	cache   CacheStore
+	ttl     time.Duration
+	mutex   sync.Mutex
+	version uint64
+}
+
+func (store *cachedStorage) String() string {
+	return "cached " + describeStore(store.Storage)
+}
+
+func (store *cachedStorage) GetReader(id string) (io.ReadCloser, error) {
+	if content, ok := store.cache.Get(id); ok {
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}
+	store.mutex.Lock()
+	version := store.version
+	store.mutex.Unlock()
+	reader, err := store.Storage.GetReader(id)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	store.mutex.Lock()
+	if store.version == version {
+		store.cache.Set(id, content, store.ttl)
+	}
+	store.mutex.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
+// invalidate removes a changed key from the cache and keeps running reads from caching it again
+func (store *cachedStorage) invalidate(id string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.version++
+	store.cache.Delete(id)
+}
+
+func (store *cachedStorage) GetWriter(id string) (io.WriteCloser, error) {
+	store.cache.Delete(id)
+	writer, err := store.Storage.GetWriter(id)
+	if err != nil {
+		return nil, err
+	}
+	return &invalidatingWriter{WriteCloser: writer, store: store, id: id}, nil
+}
+
+func (store *cachedStorage) Has(id string) bool {
+	if _, ok := store.cache.Get(id); ok {
+		return true
+	}
+	return store.Storage.Has(id)
+}
+
+func (store *cachedStorage) Delete(id string) error {
+	store.cache.Delete(id)
+	err := store.Storage.Delete(id)
+	store.invalidate(id)
+	return err
+}
+
+// invalidatingWriter invalidates the key again on Close, reads during the write may have cached the old content
+type invalidatingWriter struct {
+	io.WriteCloser
+	store *cachedStorage
+	id    string
+}
+
+func (writer *invalidatingWriter) Close() error {
+	err := writer.WriteCloser.Close()
+	writer.store.invalidate(writer.id)
+	return err
+}
+
+// lruCache is a CacheStore with a maximum number of entries evicting the least recently used ones
+type lruCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    *list.List
+	index      map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	val     []byte
+	expires time.Time
+}
+
+// NewLRUCache returns an in-memory CacheStore holding at most maxEntries keys
+func NewLRUCache(maxEntries int) CacheStore {
+	return &lruCache{
+		maxEntries: maxEntries,
+		entries:    list.New(),
+		index:      make(map[string]*list.Element),
+	}
+}
+
+func (cache *lruCache) Get(key string) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	elem, ok := cache.index[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		cache.remove(elem)
+		return nil, false
+	}
+	cache.entries.MoveToFront(elem)
+	return entry.val, true
+}
+
+func (cache *lruCache) Set(key string, val []byte, ttl time.Duration) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry := &lruEntry{key: key, val: val}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+	if elem, ok := cache.index[key]; ok {
+		elem.Value = entry
+		cache.entries.MoveToFront(elem)
+		return
+	}
+	cache.index[key] = cache.entries.PushFront(entry)
+	for cache.maxEntries > 0 && cache.entries.Len() > cache.maxEntries {
+		cache.remove(cache.entries.Back())
+	}
+}
+
+func (cache *lruCache) Delete(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if elem, ok := cache.index[key]; ok {
+		cache.remove(elem)
+	}
+}
+
+func (cache *lruCache) remove(elem *list.Element) {
+	cache.entries.Remove(elem)
+	delete(cache.index, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,132 @@
+package crud_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// countingReadStore counts the opened readers
+type countingReadStore struct {
+	streamstore.Storage
+	reads *int64
+}
+
+func (store countingReadStore) GetReader(id string) (io.ReadCloser, error) {
+	atomic.AddInt64(store.reads, 1)
+	return store.Storage.GetReader(id)
+}
+
+// pausingReadStore reads the content and then waits for resume before returning it, if pause is set
+type pausingReadStore struct {
+	streamstore.Storage
+	pause  chan struct{}
+	resume chan struct{}
+}
+
+func (store *pausingReadStore) GetReader(id string) (io.ReadCloser, error) {
+	reader, err := store.Storage.GetReader(id)
+	if err != nil || store.pause == nil {
+		return reader, err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	close(store.pause)
+	<-store.resume
+	return ioutil.NopCloser(bytes.NewReader(content)), err
+}
+
+var _ = Describe("StoreCache", func() {
+	var (
+		endpoint *Endpoint
+		reads    int64
+	)
+
+	BeforeEach(func() {
+		reads = 0
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", countingReadStore{store, &reads}, WithStoreCache(NewLRUCache(10), time.Minute)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should serve repeated reads from the cache", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		get(endpoint, "/key")
+		before := atomic.LoadInt64(&reads)
+		code, body := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		Expect(atomic.LoadInt64(&reads)).To(Equal(before))
+	})
+
+	It("should invalidate on writes and deletes", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		get(endpoint, "/key")
+		put(endpoint, "/key", `{"foo":"baz"}`)
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"foo":"baz"}`))
+		del(endpoint, "/key")
+		code, _ := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should not cache content replaced during the read", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		pausing := &pausingReadStore{Storage: store}
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", pausing, WithStoreCache(NewLRUCache(10), time.Minute)))
+		put(endpoint, "/key", `{"v":1}`)
+		pausing.pause, pausing.resume = make(chan struct{}), make(chan struct{})
+		done := make(chan string)
+		go func() {
+			_, body := get(endpoint, "/key")
+			done <- body
+		}()
+		<-pausing.pause
+		pausing.pause = nil
+		code, _ := put(endpoint, "/key", `{"v":2}`)
+		Expect(code).To(Equal(http.StatusOK))
+		close(pausing.resume)
+		Expect(<-done).To(MatchJSON(`{"v":1}`))
+		_, body := get(endpoint, "/key")
+		Expect(body).To(MatchJSON(`{"v":2}`))
+	})
+})
+
+var _ = Describe("LRUCache", func() {
+	It("should evict the least recently used entries", func() {
+		cache := NewLRUCache(2)
+		cache.Set("a", []byte("1"), 0)
+		cache.Set("b", []byte("2"), 0)
+		cache.Get("a")
+		cache.Set("c", []byte("3"), 0)
+		_, ok := cache.Get("b")
+		Expect(ok).To(BeFalse())
+		val, ok := cache.Get("a")
+		Expect(ok).To(BeTrue())
+		Expect(val).To(Equal([]byte("1")))
+	})
+
+	It("should expire entries", func() {
+		cache := NewLRUCache(2)
+		cache.Set("a", []byte("1"), time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		_, ok := cache.Get("a")
+		Expect(ok).To(BeFalse())
+	})
+})
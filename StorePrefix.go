@@ -0,0 +1,16 @@
+package crud
+
+import "errors"
+
+// WithStorePrefix stores the objects below storePfx instead of the endpoint prefix,
+// e.g. to serve the store namespace "v2_users_prod" as "users" or to migrate to a new namespace.
+// The endpoint prefix still names the endpoint in responses, events and groups.
+func WithStorePrefix(storePfx string) Option {
+	return func(endpoint *Endpoint) error {
+		if storePfx == "" {
+			return errors.New("store prefix must not be empty")
+		}
+		endpoint.storePrefix = storePfx
+		return nil
+	}
+}
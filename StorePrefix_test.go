@@ -0,0 +1,40 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StorePrefix", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should store objects below the store prefix", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("users", store, WithStorePrefix("v2_users_prod")))
+		code, _ := put(endpoint, "/alice", `{"foo":"bar"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(store.Has("v2_users_prod::alice")).To(BeTrue())
+		Expect(store.Has("users::alice")).To(BeFalse())
+
+		_, body := get(NewEndpoint("v2_users_prod", store), "/alice")
+		Expect(body).To(MatchJSON(`{"foo":"bar"}`))
+		_, body = get(endpoint, "/")
+		Expect(body).To(MatchJSON(`["alice"]`))
+	})
+
+	It("should reject an empty store prefix", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointWithOptions("users", store, WithStorePrefix(""))
+		Expect(err).To(HaveOccurred())
+	})
+})
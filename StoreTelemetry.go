@@ -0,0 +1,57 @@
+package crud
+
+import (
+	"io"
+	"time"
+
+	"github.com/trusch/streamstore"
+)
+
+// WithStoreTelemetry measures the duration of all store calls and adds them to the endpoint Metrics
+// as store.list, store.read, store.write, store.delete and store.has. For store.read and store.write
+// only opening the stream is measured, the transfer itself is part of the request latency.
+func WithStoreTelemetry() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.storeTelemetry = true
+		return nil
+	}
+}
+
+// timedStorage records the duration of each call to the wrapped store
+type timedStorage struct {
+	streamstore.Storage
+	metrics *metrics
+}
+
+func (store *timedStorage) record(label string, start time.Time) {
+	store.metrics.recordStoreCall(label, time.Since(start))
+}
+
+func (store *timedStorage) String() string {
+	return "timed " + describeStore(store.Storage)
+}
+
+func (store *timedStorage) GetReader(id string) (io.ReadCloser, error) {
+	defer store.record("store.read", time.Now())
+	return store.Storage.GetReader(id)
+}
+
+func (store *timedStorage) GetWriter(id string) (io.WriteCloser, error) {
+	defer store.record("store.write", time.Now())
+	return store.Storage.GetWriter(id)
+}
+
+func (store *timedStorage) List(prefix string) ([]string, error) {
+	defer store.record("store.list", time.Now())
+	return store.Storage.List(prefix)
+}
+
+func (store *timedStorage) Has(id string) bool {
+	defer store.record("store.has", time.Now())
+	return store.Storage.Has(id)
+}
+
+func (store *timedStorage) Delete(id string) error {
+	defer store.record("store.delete", time.Now())
+	return store.Storage.Delete(id)
+}
@@ -0,0 +1,45 @@
+package crud_test
+
+import (
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StoreTelemetry", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithStoreTelemetry()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should record the store calls", func() {
+		put(endpoint, "/key", "foobar")
+		get(endpoint, "/key")
+		get(endpoint, "/")
+		del(endpoint, "/key")
+		metrics := endpoint.Metrics()
+		for _, label := range []string{"store.write", "store.read", "store.list", "store.delete"} {
+			Expect(metrics.StoreCallCounts[label]).To(BeNumerically(">", 0), label)
+			Expect(metrics.StoreLatencyMs).To(HaveKey(label))
+		}
+	})
+
+	It("should not record store calls by default", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		plain := NewEndpoint("test", store)
+		put(plain, "/key", "foobar")
+		Expect(plain.Metrics().StoreCallCounts).To(BeEmpty())
+	})
+})
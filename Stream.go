@@ -0,0 +1,56 @@
+package crud
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// streamChunkSize is the number of bytes sent per event by GET /{id}/stream
+const streamChunkSize = 4096
+
+// handleStream serves GET /{id}/stream, which sends the content of an object as server-sent events of up to 4 KiB.
+// Chunks containing newlines are split into several data lines, so joining the data lines of an event with "\n"
+// and concatenating the events yields the content. The stream ends with "event: done" and "data: EOF".
+// Content containing carriage returns can't be reconstructed, since they end lines in event streams too.
+func (endpoint *Endpoint) handleStream(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	if endpoint.isExpired(id) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	span := endpoint.storeSpan(r, "GetReader", id)
+	defer span.End()
+	reader, err := endpoint.store.GetReader(endpoint.objectID(id))
+	if err != nil {
+		endpoint.writeStoreError(w, err)
+		return
+	}
+	defer reader.Close()
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	chunk := make([]byte, streamChunkSize)
+	for {
+		n, err := io.ReadFull(reader, chunk)
+		if n > 0 {
+			for _, line := range bytes.Split(chunk[:n], []byte("\n")) {
+				fmt.Fprintf(w, "data: %s\n", line)
+			}
+			fmt.Fprint(w, "\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			// the status is sent already, ending the stream without done event signals the failure
+			return
+		}
+	}
+	fmt.Fprint(w, "event: done\ndata: EOF\n\n")
+}
@@ -0,0 +1,62 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = NewEndpoint("test", store)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	// reconstruct joins the data lines of each event with newlines and concatenates the events until done
+	reconstruct := func(stream string) (string, int, bool) {
+		content, events := "", 0
+		for _, event := range strings.Split(stream, "\n\n") {
+			if event == "event: done\ndata: EOF" {
+				return content, events, true
+			}
+			var lines []string
+			for _, line := range strings.Split(event, "\n") {
+				lines = append(lines, strings.TrimPrefix(line, "data: "))
+			}
+			content += strings.Join(lines, "\n")
+			events++
+		}
+		return content, events, false
+	}
+
+	It("should stream the content in chunks", func() {
+		content := `{"text":"` + strings.Repeat("lorem ipsum\\n", 1000) + "\"}\n"
+		put(endpoint, "/key", content)
+		resp := request(endpoint, "GET", "/key/stream", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("text/event-stream"))
+		Expect(resp.Header().Get("Cache-Control")).To(Equal("no-cache"))
+		streamed, events, done := reconstruct(resp.Body.String())
+		Expect(done).To(BeTrue())
+		Expect(events).To(Equal((len(content) + 4095) / 4096))
+		Expect(streamed).To(Equal(content))
+	})
+
+	It("should return 404 for missing objects", func() {
+		code, _ := get(endpoint, "/missing/stream")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
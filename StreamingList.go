@@ -0,0 +1,44 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// streamingFlushInterval is the number of list entries after which the response is flushed
+const streamingFlushInterval = 100
+
+// WithStreamingList makes list requests respond with newline delimited json (application/x-ndjson)
+// instead of a single json array, flushing the response after every 100 entries.
+// The response envelope is not applied to streamed lists.
+func WithStreamingList() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.streamingList = true
+		return nil
+	}
+}
+
+func (endpoint *Endpoint) writeJSONLines(w http.ResponseWriter, list interface{}) {
+	var entries []interface{}
+	switch items := list.(type) {
+	case []string:
+		for _, item := range items {
+			entries = append(entries, item)
+		}
+	case []map[string]interface{}:
+		for _, item := range items {
+			entries = append(entries, item)
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	for i, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return
+		}
+		if flusher != nil && (i+1)%streamingFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+}
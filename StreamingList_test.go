@@ -0,0 +1,50 @@
+package crud_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StreamingList", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithStreamingList()))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should respond with json lines", func() {
+		expected := []string{}
+		for i := 0; i < 250; i++ {
+			id := fmt.Sprintf("key%03d", i)
+			put(handler, "/"+id, "foobar")
+			expected = append(expected, id)
+		}
+		resp := request(handler, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/x-ndjson"))
+		ids := []string{}
+		scanner := bufio.NewScanner(strings.NewReader(resp.Body.String()))
+		for scanner.Scan() {
+			var id string
+			Expect(json.Unmarshal(scanner.Bytes(), &id)).To(Succeed())
+			ids = append(ids, id)
+		}
+		Expect(ids).To(ConsistOf(expected))
+	})
+})
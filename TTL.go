@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithTTL lets objects expire ttl after their last modification. It enables metadata to know the modification times.
+// Expired objects are treated as missing by get, meta, multi-get and list requests, they are not deleted from the store.
+// Objects without metadata, e.g. written before the ttl was configured, don't expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if ttl <= 0 {
+			return fmt.Errorf("ttl must be positive, got %v", ttl)
+		}
+		endpoint.ttl = ttl
+		endpoint.metadata = true
+		return nil
+	}
+}
+
+// isExpired returns whether the object is older than the ttl
+func (endpoint *Endpoint) isExpired(id string) bool {
+	if endpoint.ttl == 0 {
+		return false
+	}
+	meta, ok := endpoint.loadMetadata(id)
+	return ok && time.Since(meta.UpdatedAt) > endpoint.ttl
+}
+
+// filterExpired removes the expired objects from ids
+func (endpoint *Endpoint) filterExpired(ids []string) []string {
+	if endpoint.ttl == 0 {
+		return ids
+	}
+	result := ids[:0]
+	for _, id := range ids {
+		if !endpoint.isExpired(id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
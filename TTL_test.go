@@ -0,0 +1,54 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TTL", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithTTL(50*time.Millisecond)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should hide expired objects", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		code, _ := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+		time.Sleep(100 * time.Millisecond)
+		code, _ = get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = get(endpoint, "/meta/key")
+		Expect(code).To(Equal(http.StatusNotFound))
+		_, body := get(endpoint, "/")
+		Expect(body).To(MatchJSON(`[]`))
+	})
+
+	It("should renew the ttl on writes", func() {
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		time.Sleep(30 * time.Millisecond)
+		put(endpoint, "/key", `{"foo":"baz"}`)
+		time.Sleep(30 * time.Millisecond)
+		code, _ := get(endpoint, "/key")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+
+	It("should reject invalid ttls", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithTTL(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,113 @@
+package crud
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// parseTags splits a comma separated tag list, dropping empty and duplicate tags
+func parseTags(value string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// loadTags returns the tags of an object, an object without tags sidecar has no tags
+func (endpoint *Endpoint) loadTags(id string) []string {
+	data, err := endpoint.readSidecar(id, sidecarTags)
+	if err != nil {
+		return make([]string, 0)
+	}
+	return parseTags(string(data))
+}
+
+func (endpoint *Endpoint) saveTags(id string, tags []string) error {
+	return endpoint.writeSidecar(id, sidecarTags, []byte(strings.Join(tags, ",")))
+}
+
+func (endpoint *Endpoint) filterByTag(ids []string, tag string) []string {
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		for _, t := range endpoint.loadTags(id) {
+			if t == tag {
+				res = append(res, id)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// handleAddTags adds the comma separated tags from the request body to an object
+func (endpoint *Endpoint) handleAddTags(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	if !endpoint.store.Has(endpoint.objectID(id)) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	if r.Body == nil {
+		endpoint.writeError(w, http.StatusBadRequest, "no body supplied")
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		endpoint.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tags := parseTags(strings.Join(append(endpoint.loadTags(id), string(body)), ","))
+	if err = endpoint.saveTags(id, tags); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	endpoint.notify(OperationAddTags, id)
+	endpoint.writeTags(w, tags)
+}
+
+// handleDelTag removes a single tag from an object
+func (endpoint *Endpoint) handleDelTag(w http.ResponseWriter, r *http.Request) {
+	id := endpoint.requestID(r)
+	if !endpoint.store.Has(endpoint.objectID(id)) {
+		endpoint.writeError(w, http.StatusNotFound, "object not found")
+		return
+	}
+	tags := make([]string, 0)
+	found := false
+	for _, tag := range endpoint.loadTags(id) {
+		if tag == mux.Vars(r)["tag"] {
+			found = true
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if !found {
+		endpoint.writeError(w, http.StatusNotFound, "tag not found")
+		return
+	}
+	if err := endpoint.saveTags(id, tags); err != nil {
+		endpoint.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	endpoint.notify(OperationDeleteTag, id)
+	endpoint.writeTags(w, tags)
+}
+
+func (endpoint *Endpoint) writeTags(w http.ResponseWriter, tags []string) {
+	if endpoint.envelope {
+		endpoint.writeEnvelope(w, http.StatusOK, tags, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
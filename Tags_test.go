@@ -0,0 +1,78 @@
+package crud_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+	var handler http.Handler
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler = NewEndpoint("test", store)
+		request(handler, "PUT", "/key1", "foobar", http.Header{"X-Tags": {"red,blue"}})
+		request(handler, "PUT", "/key2", "foobar", http.Header{"X-Tags": {"blue"}})
+		request(handler, "PUT", "/key3", "foobar", nil)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	list := func(path string) []string {
+		code, data := get(handler, path)
+		Expect(code).To(Equal(http.StatusOK))
+		keys := []string{}
+		Expect(json.Unmarshal([]byte(data), &keys)).To(Succeed())
+		return keys
+	}
+
+	It("should not list the tag sidecars", func() {
+		Expect(list("/")).To(ConsistOf("key1", "key2", "key3"))
+	})
+
+	It("should filter by tag", func() {
+		Expect(list("/?tag=blue")).To(ConsistOf("key1", "key2"))
+		Expect(list("/?tag=red")).To(ConsistOf("key1"))
+		Expect(list("/?tag=green")).To(BeEmpty())
+	})
+
+	It("should include tags on request", func() {
+		_, data := get(handler, "/?include_tags=true")
+		Expect(data).To(MatchJSON(`[
+			{"id":"key1","tags":["red","blue"]},
+			{"id":"key2","tags":["blue"]},
+			{"id":"key3","tags":[]}
+		]`))
+	})
+
+	It("should be possible to add tags", func() {
+		code, data := post(handler, "/key3/tags", "green, red")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`["green","red"]`))
+		Expect(list("/?tag=red")).To(ConsistOf("key1", "key3"))
+	})
+
+	It("should be possible to delete tags", func() {
+		code, data := del(handler, "/key1/tags/red")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(data).To(MatchJSON(`["blue"]`))
+		Expect(list("/?tag=red")).To(BeEmpty())
+		code, _ = del(handler, "/key1/tags/red")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+
+	It("should return 404 for tags of missing objects", func() {
+		code, _ := post(handler, "/missing/tags", "red")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,48 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const defaultThrottleTimeout = 30 * time.Second
+
+// WithRequestThrottling delays requests exceeding one request per sustained interval, allowing bursts of burst requests.
+// Unlike rejecting, waiting requests are served as soon as their slot is available. Requests which would wait longer
+// than the throttle timeout fail with 503, see WithThrottleTimeout.
+func WithRequestThrottling(burst int, sustained time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if burst < 1 || sustained <= 0 {
+			return fmt.Errorf("invalid throttling with burst %v and sustained interval %v", burst, sustained)
+		}
+		endpoint.limiter = rate.NewLimiter(rate.Every(sustained), burst)
+		return nil
+	}
+}
+
+// WithThrottleTimeout sets how long a throttled request waits at most, the default is 30s
+func WithThrottleTimeout(d time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.throttleTimeout = d
+		return nil
+	}
+}
+
+// throttle waits for the limiter. If the request would wait too long or is cancelled 503 is written and false returned.
+func (endpoint *Endpoint) throttle(w http.ResponseWriter, r *http.Request) bool {
+	if endpoint.limiter == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), endpoint.throttleTimeout)
+	defer cancel()
+	if err := endpoint.limiter.Wait(ctx); err != nil {
+		w.Header().Set("Retry-After", "1")
+		endpoint.writeError(w, http.StatusServiceUnavailable, "too many requests")
+		return false
+	}
+	return true
+}
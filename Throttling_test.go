@@ -0,0 +1,50 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Throttling", func() {
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should delay requests exceeding the burst", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithRequestThrottling(2, 50*time.Millisecond)))
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			code, _ := get(endpoint, "/")
+			Expect(code).To(Equal(http.StatusOK))
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+	})
+
+	It("should reject requests which would wait too long", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store,
+			WithRequestThrottling(1, time.Hour),
+			WithThrottleTimeout(10*time.Millisecond),
+		))
+		code, _ := get(endpoint, "/")
+		Expect(code).To(Equal(http.StatusOK))
+		resp := request(endpoint, "GET", "/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(resp.Header().Get("Retry-After")).NotTo(BeEmpty())
+	})
+
+	It("should reject invalid settings", func() {
+		_, err := NewEndpointWithOptions("test", nil, WithRequestThrottling(0, time.Second))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,47 @@
+package crud
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer starts spans, it is implemented by adapters to tracing libraries like OpenTelemetry.
+// The context passed to Start is the request context, so the adapter can make the span a child
+// of the span of the HTTP layer.
+type Tracer interface {
+	Start(ctx context.Context, name string) Span
+}
+
+// Span is a started span of a Tracer
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// WithSpanStore records a span for each store call on objects, named crud.store.List, crud.store.GetReader,
+// crud.store.GetWriter and crud.store.Delete, with the attributes store.prefix, object.id and,
+// for reads and writes, io.bytes. Sidecar accesses are not traced.
+func WithSpanStore(tracer Tracer) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.tracer = tracer
+		return nil
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) End()                                       {}
+
+// storeSpan starts the span of a store call, it is a no-op if tracing is disabled
+func (endpoint *Endpoint) storeSpan(r *http.Request, call, id string) Span {
+	if endpoint.tracer == nil {
+		return noopSpan{}
+	}
+	span := endpoint.tracer.Start(r.Context(), "crud.store."+call)
+	span.SetAttribute("store.prefix", endpoint.storePrefix)
+	if id != "" {
+		span.SetAttribute("object.id", id)
+	}
+	return span
+}
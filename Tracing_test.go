@@ -0,0 +1,76 @@
+package crud_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recordedSpan struct {
+	name       string
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (span *recordedSpan) SetAttribute(key string, value interface{}) {
+	span.attributes[key] = value
+}
+
+func (span *recordedSpan) End() {
+	span.ended = true
+}
+
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func (tracer *recordingTracer) Start(ctx context.Context, name string) Span {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	span := &recordedSpan{name: name, attributes: make(map[string]interface{})}
+	tracer.spans = append(tracer.spans, span)
+	return span
+}
+
+var _ = Describe("Tracing", func() {
+	var (
+		handler http.Handler
+		tracer  *recordingTracer
+	)
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		tracer = &recordingTracer{}
+		handler = mustEndpoint(NewEndpointWithOptions("test", store, WithSpanStore(tracer)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should record spans of the store calls", func() {
+		put(handler, "/key", "foobar")
+		get(handler, "/key")
+		get(handler, "/")
+		del(handler, "/key")
+		names := []string{}
+		for _, span := range tracer.spans {
+			Expect(span.ended).To(BeTrue())
+			Expect(span.attributes).To(HaveKeyWithValue("store.prefix", "test"))
+			names = append(names, span.name)
+		}
+		Expect(names).To(Equal([]string{"crud.store.GetWriter", "crud.store.GetReader", "crud.store.List", "crud.store.Delete"}))
+		Expect(tracer.spans[0].attributes).To(HaveKeyWithValue("io.bytes", int64(6)))
+		Expect(tracer.spans[1].attributes).To(HaveKeyWithValue("object.id", "key"))
+		Expect(tracer.spans[1].attributes).To(HaveKeyWithValue("io.bytes", int64(6)))
+	})
+})
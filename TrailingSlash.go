@@ -0,0 +1,42 @@
+package crud
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithTrailingSlashRedirect answers GET and HEAD requests for "/{id}/" with 301 to "/{id}", and collapses
+// repeated slashes like "//" to "/" the same way. The query string is kept.
+// Without it "/{id}/" doesn't match any route and gets 404.
+func WithTrailingSlashRedirect() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.slashRedirect = true
+		return nil
+	}
+}
+
+// redirectSlashes redirects requests with repeated slashes in the path and returns whether it did so.
+// It runs before the router, which would redirect them itself but drop the query string and the mount point.
+func (endpoint *Endpoint) redirectSlashes(w http.ResponseWriter, r *http.Request) bool {
+	if !endpoint.slashRedirect || (r.Method != "GET" && r.Method != "HEAD") || !strings.Contains(r.URL.Path, "//") {
+		return false
+	}
+	path := requestPath(r)
+	for strings.Contains(path, "//") {
+		path = strings.Replace(path, "//", "/", -1)
+	}
+	redirectTo(w, r, path)
+	return true
+}
+
+// handleTrailingSlash redirects "/{id}/" to "/{id}"
+func (endpoint *Endpoint) handleTrailingSlash(w http.ResponseWriter, r *http.Request) {
+	redirectTo(w, r, strings.TrimSuffix(requestPath(r), "/"))
+}
+
+func redirectTo(w http.ResponseWriter, r *http.Request, path string) {
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, path, http.StatusMovedPermanently)
+}
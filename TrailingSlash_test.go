@@ -0,0 +1,52 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrailingSlash", func() {
+	var endpoint *Endpoint
+
+	BeforeEach(func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		endpoint = mustEndpoint(NewEndpointWithOptions("test", store, WithTrailingSlashRedirect()))
+		put(endpoint, "/abc", `{"foo":"bar"}`)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should redirect to the id without trailing slash", func() {
+		resp := request(endpoint, "GET", "/abc/?pretty=true", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusMovedPermanently))
+		Expect(resp.Header().Get("Location")).To(Equal("/abc?pretty=true"))
+		resp = request(endpoint, "HEAD", "/abc/", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusMovedPermanently))
+	})
+
+	It("should collapse repeated slashes", func() {
+		resp := request(endpoint, "GET", "//?limit=1", "", nil)
+		Expect(resp.Code).To(Equal(http.StatusMovedPermanently))
+		Expect(resp.Header().Get("Location")).To(Equal("/?limit=1"))
+		resp = request(endpoint, "GET", "/abc//", "", nil)
+		Expect(resp.Header().Get("Location")).To(Equal("/abc/"))
+	})
+
+	It("should only redirect reads with the option", func() {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		code, _ := get(NewEndpoint("test", store), "/abc/")
+		Expect(code).To(Equal(http.StatusNotFound))
+		code, _ = put(endpoint, "/abc/", `{}`)
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
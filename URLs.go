@@ -0,0 +1,57 @@
+package crud
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// absoluteURL builds an absolute url pointing to this endpoint based on the incoming request,
+// or on the base url of WithAbsoluteURLs
+func (endpoint *Endpoint) absoluteURL(r *http.Request, path string, query url.Values) string {
+	if endpoint.baseURL != nil {
+		mount := strings.TrimSuffix(requestPath(r), r.URL.Path)
+		u := *endpoint.baseURL
+		u.Path = strings.TrimSuffix(u.Path, "/") + strings.TrimPrefix(path, mount)
+		u.RawPath = ""
+		if query != nil {
+			u.RawQuery = query.Encode()
+		}
+		return u.String()
+	}
+	u := url.URL{
+		Scheme: "http",
+		Host:   r.Host,
+		Path:   path,
+	}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	if r.URL.Scheme != "" {
+		u.Scheme = r.URL.Scheme
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// objectURL returns the absolute url of an object.
+// For requests to the collection the id is appended to the request path.
+func (endpoint *Endpoint) objectURL(r *http.Request, id string) string {
+	path := requestPath(r)
+	if strings.HasSuffix(path, "/") {
+		path += url.PathEscape(id)
+	}
+	return endpoint.absoluteURL(r, path, nil)
+}
+
+// requestPath returns the path as requested by the client, even if the endpoint is mounted below a stripped prefix
+func requestPath(r *http.Request) string {
+	if r.RequestURI != "" {
+		if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+			return u.Path
+		}
+	}
+	return r.URL.Path
+}
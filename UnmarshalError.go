@@ -0,0 +1,55 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// UnmarshalErrorFunc returns the response for a json parse error of a request body or a stored object
+type UnmarshalErrorFunc func(err error) (status int, body []byte)
+
+// WithUnmarshalError replaces the response written when parsing json fails, e.g. to add error ids or to log
+// the full error. The default DefaultUnmarshalError hides the parser error from clients.
+// The body is written as is, a body which is valid json gets the Content-Type application/json.
+func WithUnmarshalError(fn func(err error) (status int, body []byte)) Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.unmarshalError = fn
+		return nil
+	}
+}
+
+// DefaultUnmarshalError responds with {"error":"invalid JSON"} and 400, or 500 if a stored object is broken
+func DefaultUnmarshalError(err error) (int, []byte) {
+	status := http.StatusBadRequest
+	if e, ok := err.(*unmarshalError); ok && e.stored {
+		status = http.StatusInternalServerError
+	}
+	return status, []byte(`{"error":"invalid JSON"}`)
+}
+
+// unmarshalError marks json parse errors, stored is set if a stored object couldn't be parsed
+type unmarshalError struct {
+	err    error
+	stored bool
+}
+
+func (e *unmarshalError) Error() string {
+	if e.stored {
+		return "invalid stored json: " + e.err.Error()
+	}
+	return "invalid json: " + e.err.Error()
+}
+
+// writeUnmarshalError writes the configured response for a json parse error
+func (endpoint *Endpoint) writeUnmarshalError(w http.ResponseWriter, err error) {
+	fn := endpoint.unmarshalError
+	if fn == nil {
+		fn = DefaultUnmarshalError
+	}
+	status, body := fn(err)
+	if json.Valid(body) {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,52 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnmarshalError", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should hide parse errors by default", func() {
+		endpoint := NewEndpoint("test", store)
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		resp := request(endpoint, "PATCH", "/key", `{broken`, nil)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":"invalid JSON"}`))
+		put(endpoint, "/broken", `not json`)
+		resp = request(endpoint, "PATCH", "/broken", `{"foo":"bar"}`, nil)
+		Expect(resp.Code).To(Equal(http.StatusInternalServerError))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":"invalid JSON"}`))
+	})
+
+	It("should use the custom function", func() {
+		var seen error
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithUnmarshalError(func(err error) (int, []byte) {
+			seen = err
+			return http.StatusUnprocessableEntity, []byte(`{"error":"invalid JSON","id":"E42"}`)
+		})))
+		put(endpoint, "/key", `{"foo":"bar"}`)
+		resp := request(endpoint, "PATCH", "/key", `{broken`, nil)
+		Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+		Expect(resp.Body.String()).To(MatchJSON(`{"error":"invalid JSON","id":"E42"}`))
+		Expect(seen).To(MatchError(ContainSubstring("invalid character")))
+	})
+})
@@ -0,0 +1,59 @@
+package crud
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ValidateID checks whether id is safe to be used as object id.
+// It rejects empty ids, path separators, relative path elements,
+// control characters and ids which would collide with sidecars of an endpoint with the default delimiter.
+func ValidateID(id string) error {
+	return validateID(id, defaultDelimiter)
+}
+
+// validateID works like ValidateID for an endpoint with the given delimiter
+func validateID(id, delimiter string) error {
+	if id == "" {
+		return errors.New("id must not be empty")
+	}
+	if id == "." || id == ".." || strings.Contains(id, "..") {
+		return errors.New("id must not contain relative path elements")
+	}
+	if strings.ContainsAny(id, "/\\") {
+		return errors.New("id must not contain path separators")
+	}
+	for _, r := range id {
+		if unicode.IsControl(r) {
+			return errors.New("id must not contain control characters")
+		}
+	}
+	if isSidecar(id, delimiter) {
+		return errors.New("id must not end with a reserved sidecar name")
+	}
+	return nil
+}
+
+// withValidID wraps the handlers of operations on single objects with validateID, so clients can't
+// address sidecars or escape the prefix with relative path elements. Invalid ids get 400.
+func (endpoint *Endpoint) withValidID(op Operation, fn http.HandlerFunc) http.HandlerFunc {
+	switch op {
+	case OperationList, OperationQuery, OperationPost, OperationPatchAll, OperationDeleteAll, OperationWatch, OperationStats, OperationImport:
+		return fn
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		ids := []string{endpoint.requestID(r)}
+		if op == OperationMove {
+			ids = append(ids, r.URL.Query().Get("to"))
+		}
+		for _, id := range ids {
+			if err := validateID(id, endpoint.delimiter); err != nil {
+				endpoint.writeError(w, http.StatusBadRequest, "invalid id "+id+": "+err.Error())
+				return
+			}
+		}
+		fn(w, r)
+	}
+}
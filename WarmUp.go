@@ -0,0 +1,107 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultWarmUpConcurrency = 4
+
+// WithWarmUpConcurrency sets how many objects WarmUp reads in parallel, the default is 4.
+// Fails if n is smaller than 1.
+func WithWarmUpConcurrency(n int) Option {
+	return func(endpoint *Endpoint) error {
+		if n < 1 {
+			return errors.New("warm up concurrency must be at least 1")
+		}
+		endpoint.warmUpConcurrency = n
+		return nil
+	}
+}
+
+// WithWarmUpOnStart calls WarmUp in the background after construction, errors are logged
+func WithWarmUpOnStart() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.warmUpOnStart = true
+		return nil
+	}
+}
+
+// WarmUp reads all objects once to fill the cache of WithStoreCache, so the first requests after a restart are fast.
+// Objects which fail to load are logged and skipped, the first of these errors is returned after all objects
+// were read. It stops early with the context error if ctx is done. Without a store cache it does nothing.
+func (endpoint *Endpoint) WarmUp(ctx context.Context) error {
+	if endpoint.cache == nil {
+		return nil
+	}
+	ids, err := endpoint.listIDs()
+	if err != nil {
+		return err
+	}
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		firstErr error
+		jobs     = make(chan string)
+	)
+	for i := 0; i < endpoint.warmUpConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				if err := endpoint.warmUp(id); err != nil {
+					log.Warnf("failed to warm up %v: %v", id, err)
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	err = nil
+dispatch:
+	for _, id := range endpoint.filterExpired(ids) {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// warmUp reads an object through the cache
+func (endpoint *Endpoint) warmUp(id string) error {
+	reader, err := endpoint.store.GetReader(endpoint.objectID(id))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.Copy(ioutil.Discard, reader)
+	return err
+}
+
+// startWarmUp runs WarmUp in the background if WithWarmUpOnStart is set
+func (endpoint *Endpoint) startWarmUp() {
+	if !endpoint.warmUpOnStart {
+		return
+	}
+	go func() {
+		if err := endpoint.WarmUp(context.Background()); err != nil {
+			log.Errorf("failed to warm up cache of %v: %v", endpoint.prefix, err)
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package crud_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// warmUpStore counts the GetReader calls and fails reads of the broken key
+type warmUpStore struct {
+	streamstore.Storage
+	reads  int32
+	broken string
+}
+
+func (store *warmUpStore) GetReader(key string) (io.ReadCloser, error) {
+	atomic.AddInt32(&store.reads, 1)
+	if key == store.broken {
+		return nil, errors.New("broken object")
+	}
+	return store.Storage.GetReader(key)
+}
+
+var _ = Describe("WarmUp", func() {
+	var store *warmUpStore
+
+	BeforeEach(func() {
+		inner, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		store = &warmUpStore{Storage: inner}
+		for _, id := range []string{"a", "b", "c"} {
+			put(NewEndpoint("test", inner), "/"+id, `{}`)
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should fill the cache", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreCache(NewLRUCache(10), 0), WithWarmUpConcurrency(2)))
+		Expect(endpoint.WarmUp(context.Background())).To(Succeed())
+		Expect(atomic.LoadInt32(&store.reads)).To(Equal(int32(3)))
+		for _, id := range []string{"a", "b", "c"} {
+			code, _ := get(endpoint, "/"+id)
+			Expect(code).To(Equal(http.StatusOK))
+		}
+		Expect(atomic.LoadInt32(&store.reads)).To(Equal(int32(3)))
+	})
+
+	It("should continue after failures and return the first error", func() {
+		store.broken = "test::b"
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreCache(NewLRUCache(10), 0)))
+		Expect(endpoint.WarmUp(context.Background())).To(MatchError("broken object"))
+		Expect(atomic.LoadInt32(&store.reads)).To(Equal(int32(3)))
+	})
+
+	It("should stop when the context is done", func() {
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithStoreCache(NewLRUCache(10), 0)))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		Expect(endpoint.WarmUp(ctx)).To(MatchError(context.Canceled))
+	})
+
+	It("should warm up in the background on start", func() {
+		mustEndpoint(NewEndpointWithOptions("test", store, WithStoreCache(NewLRUCache(10), 0), WithWarmUpOnStart()))
+		Eventually(func() int32 { return atomic.LoadInt32(&store.reads) }).Should(Equal(int32(3)))
+	})
+
+	It("should do nothing without cache", func() {
+		Expect(NewEndpoint("test", store).WarmUp(context.Background())).To(Succeed())
+		Expect(atomic.LoadInt32(&store.reads)).To(BeZero())
+		_, err := NewEndpointWithOptions("test", store, WithWarmUpConcurrency(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
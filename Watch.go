@@ -0,0 +1,117 @@
+package crud
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// watchBufferSize is the number of events buffered per watcher, slower watchers miss events
+const watchBufferSize = 64
+
+// WithWatchEndpoint serves GET /watch, a server-sent events stream which emits an event
+// {"op":"<operation>","id":"<id>"} after each successful write, with the operation as event name.
+// Writes of several objects emit an event per object, a move for source and destination, deleting all objects one with an empty id.
+// The route takes precedence over GET /{id}. Watch requests occupy a WithMaxConcurrent slot while they are open.
+func WithWatchEndpoint() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.watchers = newWatchHub()
+		return nil
+	}
+}
+
+// WithKeepAlive makes watch streams send a ": ping" comment every d,
+// so proxies don't close them as idle connections
+func WithKeepAlive(d time.Duration) Option {
+	return func(endpoint *Endpoint) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid keep-alive interval %v", d)
+		}
+		endpoint.keepAlive = d
+		return nil
+	}
+}
+
+// watchEvent is a change notification sent to watchers
+type watchEvent struct {
+	Op Operation `json:"op"`
+	ID string    `json:"id,omitempty"`
+}
+
+// watchHub distributes events to the connected watchers
+type watchHub struct {
+	mutex       sync.Mutex
+	subscribers map[chan watchEvent]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{subscribers: make(map[chan watchEvent]struct{})}
+}
+
+func (hub *watchHub) subscribe() chan watchEvent {
+	ch := make(chan watchEvent, watchBufferSize)
+	hub.mutex.Lock()
+	hub.subscribers[ch] = struct{}{}
+	hub.mutex.Unlock()
+	return ch
+}
+
+func (hub *watchHub) unsubscribe(ch chan watchEvent) {
+	hub.mutex.Lock()
+	delete(hub.subscribers, ch)
+	hub.mutex.Unlock()
+}
+
+func (hub *watchHub) publish(event watchEvent) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	for ch := range hub.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// notify publishes a change to the watchers, if the watch endpoint is enabled
+func (endpoint *Endpoint) notify(op Operation, id string) {
+	if endpoint.watchers != nil {
+		endpoint.watchers.publish(watchEvent{Op: op, ID: id})
+	}
+}
+
+func (endpoint *Endpoint) handleWatch(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		endpoint.writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	events := endpoint.watchers.subscribe()
+	defer endpoint.watchers.unsubscribe(events)
+
+	var keepAlive <-chan time.Time
+	if endpoint.keepAlive > 0 {
+		ticker := time.NewTicker(endpoint.keepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive:
+			fmt.Fprint(w, ": ping\n\n")
+		case event := <-events:
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "event: %v\ndata: %s\n\n", event.Op, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,93 @@
+package crud_test
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watch", func() {
+	var (
+		server *httptest.Server
+		lines  chan string
+		resp   *http.Response
+	)
+
+	start := func(opts ...Option) {
+		store, err := uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewServer(mustEndpoint(NewEndpointWithOptions("test", store, opts...)))
+		resp, err = http.Get(server.URL + "/watch")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+		lines = make(chan string, 100)
+		go func(lines chan string, body io.Reader) {
+			defer GinkgoRecover()
+			scanner := bufio.NewScanner(body)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			close(lines)
+		}(lines, resp.Body)
+	}
+
+	AfterEach(func() {
+		resp.Body.Close()
+		server.Close()
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should stream change events", func() {
+		start(WithWatchEndpoint())
+		req, _ := http.NewRequest("PUT", server.URL+"/key", strings.NewReader("foobar"))
+		_, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(lines).Should(Receive(Equal("event: put")))
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"put","id":"key"}`)))
+	})
+
+	It("should stream events of bulk and tag changes", func() {
+		start(WithWatchEndpoint())
+		do := func(method, path, body, contentType string) {
+			req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+		}
+		do("PUT", "/import", zipArchive(map[string]string{"key.json": `{"a":1}`}), "application/zip")
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"import","id":"key"}`)))
+		do("PATCH", "/", `[{"op":"replace","path":"/a","value":2}]`, "application/json-patch+json")
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"patch_all","id":"key"}`)))
+		do("POST", "/key/tags", "red", "")
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"add_tags","id":"key"}`)))
+		do("DELETE", "/key/tags/red", "", "")
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"delete_tag","id":"key"}`)))
+		do("MOVE", "/key?to=other", "", "")
+		Eventually(lines).Should(Receive(Equal(`data: {"op":"move","id":"other"}`)))
+	})
+
+	It("should send keep-alive comments", func() {
+		start(WithWatchEndpoint(), WithKeepAlive(50*time.Millisecond))
+		Eventually(lines, 100*time.Millisecond).Should(Receive(Equal(": ping")))
+	})
+
+	It("should reject invalid keep-alive intervals", func() {
+		start(WithWatchEndpoint())
+		_, err := NewEndpointWithOptions("test", nil, WithKeepAlive(0))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,38 @@
+package crud
+
+import (
+	"errors"
+	"time"
+)
+
+// WithWatchdogTimer calls action if the endpoint served no request for d, e.g. to log a warning, publish a metric
+// or exit a zombie process. The timer starts at construction time and every request resets it, so constant traffic
+// never triggers it. After action was called the timer only starts again with the next request.
+// Fails if d isn't positive or action is nil.
+func WithWatchdogTimer(d time.Duration, action func(*Endpoint)) Option {
+	return func(endpoint *Endpoint) error {
+		if d <= 0 || action == nil {
+			return errors.New("watchdog timer needs a positive duration and an action")
+		}
+		endpoint.watchdogDelay = d
+		endpoint.watchdogAction = action
+		return nil
+	}
+}
+
+// startWatchdog starts the watchdog timer if one is configured and not shared
+func (endpoint *Endpoint) startWatchdog() {
+	if endpoint.watchdogAction == nil || endpoint.watchdog != nil {
+		return
+	}
+	endpoint.watchdog = time.AfterFunc(endpoint.watchdogDelay, func() {
+		endpoint.watchdogAction(endpoint)
+	})
+}
+
+// resetWatchdog postpones the watchdog action after a request arrived
+func (endpoint *Endpoint) resetWatchdog() {
+	if endpoint.watchdog != nil {
+		endpoint.watchdog.Reset(endpoint.watchdogDelay)
+	}
+}
@@ -0,0 +1,51 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watchdog", func() {
+	var store streamstore.Storage
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should call the action if no request arrives", func() {
+		var called int32
+		endpoint := mustEndpoint(NewEndpointWithOptions("test", store, WithWatchdogTimer(50*time.Millisecond, func(*Endpoint) {
+			atomic.AddInt32(&called, 1)
+		})))
+		for i := 0; i < 5; i++ {
+			time.Sleep(20 * time.Millisecond)
+			code, _ := get(endpoint, "/")
+			Expect(code).To(Equal(http.StatusOK))
+		}
+		Expect(atomic.LoadInt32(&called)).To(BeZero())
+		Eventually(func() int32 { return atomic.LoadInt32(&called) }).Should(Equal(int32(1)))
+		Consistently(func() int32 { return atomic.LoadInt32(&called) }, 150*time.Millisecond).Should(Equal(int32(1)))
+	})
+
+	It("should fail without duration or action", func() {
+		_, err := NewEndpointWithOptions("test", store, WithWatchdogTimer(0, func(*Endpoint) {}))
+		Expect(err).To(HaveOccurred())
+		_, err = NewEndpointWithOptions("test", store, WithWatchdogTimer(time.Second, nil))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,77 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// WithYAMLInput converts YAML bodies of POST and PUT requests to JSON before they are stored.
+// PATCH always accepts YAML bodies.
+func WithYAMLInput() Option {
+	return func(endpoint *Endpoint) error {
+		endpoint.yamlInput = true
+		return nil
+	}
+}
+
+// isYAML returns whether the request body is declared as YAML
+func isYAML(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/x-yaml", "application/yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
+
+// yamlToJSON converts a YAML document to JSON
+func yamlToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// decodeYAMLObject parses a YAML document into a json compatible map, checking the body limits
+func (endpoint *Endpoint) decodeYAMLObject(reader io.Reader, obj *map[string]interface{}) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	encoded, err := yamlToJSON(data)
+	if err != nil {
+		return err
+	}
+	if err = endpoint.checkJSONLimits(encoded); err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, obj)
+}
+
+// convertYAMLBody replaces a YAML request body by its JSON representation, checking the body limits
+func (endpoint *Endpoint) convertYAMLBody(r *http.Request) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	encoded, err := yamlToJSON(data)
+	if err != nil {
+		return err
+	}
+	if err = endpoint.checkJSONLimits(encoded); err != nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(encoded))
+	r.Header.Set("Content-Type", "application/json")
+	return nil
+}
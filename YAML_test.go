@@ -0,0 +1,63 @@
+package crud_test
+
+import (
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud"
+	"github.com/trusch/streamstore"
+	"github.com/trusch/streamstore/uriparser"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("YAML", func() {
+	var (
+		store streamstore.Storage
+		yaml  = http.Header{"Content-Type": {"application/x-yaml"}}
+	)
+
+	BeforeEach(func() {
+		var err error
+		store, err = uriparser.NewFromURI("file:///tmp/test", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test")
+	})
+
+	It("should merge YAML patches", func() {
+		handler := NewEndpoint("test", store)
+		put(handler, "/key", `{"a":1}`)
+		resp := request(handler, "PATCH", "/key", "b: 2\nc:\n  d: [x, y]\n", yaml)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		_, data := get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"a":1,"b":2,"c":{"d":["x","y"]}}`))
+	})
+
+	It("should reject malformed YAML patches", func() {
+		handler := NewEndpoint("test", store)
+		put(handler, "/key", `{"a":1}`)
+		resp := request(handler, "PATCH", "/key", "b: [unclosed", yaml)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should store YAML input as JSON", func() {
+		handler := mustEndpoint(NewEndpointWithOptions("test", store, WithYAMLInput()))
+		resp := request(handler, "PUT", "/key", "a: 1\nb: foo\n", yaml)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		_, data := get(handler, "/key")
+		Expect(data).To(MatchJSON(`{"a":1,"b":"foo"}`))
+		resp = request(handler, "POST", "/", "a: [unclosed", yaml)
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("should store YAML input as is without the option", func() {
+		handler := NewEndpoint("test", store)
+		request(handler, "PUT", "/key", "a: 1\n", yaml)
+		_, data := get(handler, "/key")
+		Expect(data).To(Equal("a: 1\n"))
+	})
+})
@@ -0,0 +1,76 @@
+// Package config constructs crud endpoints from environment variables or command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/trusch/crud"
+	"github.com/trusch/streamstore/uriparser"
+)
+
+// endpointConfig is the configuration read by the NewEndpointFrom* constructors
+type endpointConfig struct {
+	prefix         string
+	storeURI       string
+	maxBodySize    int64
+	readOnly       bool
+	allowedOrigins []string
+	apiKeys        map[string]string
+	ttl            time.Duration
+	logLevel       string
+}
+
+// newEndpoint opens the store and constructs the endpoint. A log level applies to a logger of the endpoint's
+// requests only, the level of the global logrus logger is left to the caller.
+func (config *endpointConfig) newEndpoint() (*crud.Endpoint, error) {
+	if config.prefix == "" {
+		return nil, errors.New("no prefix configured")
+	}
+	if config.storeURI == "" {
+		return nil, errors.New("no store uri configured")
+	}
+	var opts []crud.Option
+	if config.logLevel != "" {
+		level, err := log.ParseLevel(config.logLevel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, crud.WithRequestLogger(requestLogger(level)))
+	}
+	if config.maxBodySize < 0 {
+		return nil, fmt.Errorf("max body size must not be negative, got %v", config.maxBodySize)
+	}
+	if config.maxBodySize > 0 {
+		opts = append(opts, crud.WithMaxBodySize(config.maxBodySize))
+	}
+	if config.readOnly {
+		opts = append(opts, crud.WithReadOnly())
+	}
+	if len(config.allowedOrigins) > 0 {
+		opts = append(opts, crud.WithAllowedOrigins(config.allowedOrigins...))
+	}
+	if len(config.apiKeys) > 0 {
+		opts = append(opts, crud.WithAPIKeys(config.apiKeys))
+	}
+	if config.ttl != 0 {
+		opts = append(opts, crud.WithTTL(config.ttl))
+	}
+	store, err := uriparser.NewFromURI(config.storeURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not open store %v: %v", config.storeURI, err)
+	}
+	return crud.NewEndpointWithOptions(config.prefix, store, opts...)
+}
+
+// requestLogger logs each request on debug level like crud.DefaultAccessLogger, but using its own logrus logger
+func requestLogger(level log.Level) crud.RequestLogger {
+	logger := log.New()
+	logger.SetLevel(level)
+	return func(r *http.Request, status int, duration time.Duration, bytesWritten int64) {
+		logger.Debugf("%v request to %v", r.Method, r.URL)
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trusch/crud"
+)
+
+// NewEndpointFromEnv constructs an endpoint configured by environment variables:
+//
+//	CRUD_PREFIX (required)      the object key prefix
+//	CRUD_STORE_URI (required)   the streamstore uri, e.g. file:///var/lib/crud
+//	CRUD_MAX_BODY_SIZE          see crud.WithMaxBodySize, in bytes
+//	CRUD_READ_ONLY              see crud.WithReadOnly, a boolean like true or 1
+//	CRUD_ALLOWED_ORIGINS        see crud.WithAllowedOrigins, comma separated
+//	CRUD_API_KEYS               see crud.WithAPIKeys, a json map of api key to identity
+//	CRUD_TTL                    see crud.WithTTL, a duration like 24h
+//	CRUD_LOG_LEVEL              the logrus level of the request logger, e.g. debug
+//
+// NewEndpointFromFlags reads the same settings from command-line flags.
+func NewEndpointFromEnv() (*crud.Endpoint, error) {
+	config := &endpointConfig{
+		prefix:   os.Getenv("CRUD_PREFIX"),
+		storeURI: os.Getenv("CRUD_STORE_URI"),
+		logLevel: os.Getenv("CRUD_LOG_LEVEL"),
+	}
+	if config.prefix == "" {
+		return nil, missingEnv("CRUD_PREFIX")
+	}
+	if config.storeURI == "" {
+		return nil, missingEnv("CRUD_STORE_URI")
+	}
+	var err error
+	if value := os.Getenv("CRUD_MAX_BODY_SIZE"); value != "" {
+		if config.maxBodySize, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, invalidEnv("CRUD_MAX_BODY_SIZE", err)
+		}
+	}
+	if value := os.Getenv("CRUD_READ_ONLY"); value != "" {
+		if config.readOnly, err = strconv.ParseBool(value); err != nil {
+			return nil, invalidEnv("CRUD_READ_ONLY", err)
+		}
+	}
+	if value := os.Getenv("CRUD_ALLOWED_ORIGINS"); value != "" {
+		for _, origin := range strings.Split(value, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.allowedOrigins = append(config.allowedOrigins, origin)
+			}
+		}
+	}
+	if value := os.Getenv("CRUD_API_KEYS"); value != "" {
+		if err = json.Unmarshal([]byte(value), &config.apiKeys); err != nil {
+			return nil, invalidEnv("CRUD_API_KEYS", err)
+		}
+	}
+	if value := os.Getenv("CRUD_TTL"); value != "" {
+		if config.ttl, err = time.ParseDuration(value); err != nil {
+			return nil, invalidEnv("CRUD_TTL", err)
+		}
+	}
+	endpoint, err := config.newEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment configuration: %v", err)
+	}
+	return endpoint, nil
+}
+
+func missingEnv(name string) error {
+	return fmt.Errorf("environment variable %v is required", name)
+}
+
+func invalidEnv(name string, err error) error {
+	return fmt.Errorf("invalid environment variable %v: %v", name, err)
+}
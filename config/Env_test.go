@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"net/http"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	. "github.com/trusch/crud/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Env", func() {
+	variables := []string{"CRUD_PREFIX", "CRUD_STORE_URI", "CRUD_READ_ONLY", "CRUD_API_KEYS", "CRUD_TTL", "CRUD_LOG_LEVEL"}
+
+	BeforeEach(func() {
+		os.Setenv("CRUD_PREFIX", "test")
+		os.Setenv("CRUD_STORE_URI", "file:///tmp/test-config")
+	})
+
+	AfterEach(func() {
+		for _, name := range variables {
+			os.Unsetenv(name)
+		}
+		os.RemoveAll("/tmp/test-config")
+	})
+
+	It("should construct an endpoint from the environment", func() {
+		os.Setenv("CRUD_READ_ONLY", "true")
+		os.Setenv("CRUD_API_KEYS", `{"secret":"alice"}`)
+		os.Setenv("CRUD_TTL", "1h")
+		os.Setenv("CRUD_LOG_LEVEL", "info")
+		endpoint, err := NewEndpointFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		key := http.Header{"X-Api-Key": {"secret"}}
+		Expect(request(endpoint, "GET", "/", "", key).Code).To(Equal(http.StatusOK))
+		Expect(request(endpoint, "PUT", "/key", "{}", key).Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(request(endpoint, "GET", "/", "", nil).Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should leave the global log level alone", func() {
+		level := log.GetLevel()
+		os.Setenv("CRUD_LOG_LEVEL", "panic")
+		endpoint, err := NewEndpointFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(request(endpoint, "GET", "/", "", nil).Code).To(Equal(http.StatusOK))
+		Expect(log.GetLevel()).To(Equal(level))
+		os.Setenv("CRUD_LOG_LEVEL", "loud")
+		_, err = NewEndpointFromEnv()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should require a prefix and a store", func() {
+		os.Unsetenv("CRUD_STORE_URI")
+		_, err := NewEndpointFromEnv()
+		Expect(err).To(MatchError(ContainSubstring("CRUD_STORE_URI")))
+	})
+
+	It("should reject invalid values", func() {
+		os.Setenv("CRUD_TTL", "forever")
+		_, err := NewEndpointFromEnv()
+		Expect(err).To(MatchError(ContainSubstring("CRUD_TTL")))
+		os.Setenv("CRUD_TTL", "1h")
+		os.Setenv("CRUD_API_KEYS", "secret")
+		_, err = NewEndpointFromEnv()
+		Expect(err).To(MatchError(ContainSubstring("CRUD_API_KEYS")))
+	})
+})
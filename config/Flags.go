@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/trusch/crud"
+)
+
+// flagNames are the flags registered by NewEndpointFromFlags
+var flagNames = []string{
+	"crud-prefix", "crud-store", "crud-max-body-size", "crud-read-only",
+	"crud-allowed-origins", "crud-api-keys", "crud-ttl", "crud-log-level",
+}
+
+// NewEndpointFromFlags registers the flags --crud-prefix, --crud-store, --crud-max-body-size, --crud-read-only,
+// --crud-allowed-origins, --crud-api-keys, --crud-ttl and --crud-log-level on fs. They take the same values as
+// the variables of NewEndpointFromEnv. The returned function constructs the endpoint and has to be called after
+// fs was parsed. Registering fails if one of the flags is already defined on fs.
+func NewEndpointFromFlags(fs *flag.FlagSet) (func() (*crud.Endpoint, error), error) {
+	if fs == nil {
+		return nil, errors.New("no flag set given")
+	}
+	for _, name := range flagNames {
+		if fs.Lookup(name) != nil {
+			return nil, fmt.Errorf("flag %v is already defined", name)
+		}
+	}
+	config := &endpointConfig{}
+	fs.StringVar(&config.prefix, "crud-prefix", "", "the object key prefix (required)")
+	fs.StringVar(&config.storeURI, "crud-store", "", "the streamstore uri, e.g. file:///var/lib/crud (required)")
+	fs.Int64Var(&config.maxBodySize, "crud-max-body-size", 0, "the max request body size in bytes, 0 is unlimited")
+	fs.BoolVar(&config.readOnly, "crud-read-only", false, "reject all writes")
+	origins := fs.String("crud-allowed-origins", "", "comma separated CORS origins")
+	apiKeys := fs.String("crud-api-keys", "", "json map of api key to identity")
+	fs.DurationVar(&config.ttl, "crud-ttl", 0, "the object ttl, e.g. 24h, 0 keeps objects forever")
+	fs.StringVar(&config.logLevel, "crud-log-level", "", "the logrus level of the request logger, e.g. debug")
+	return func() (*crud.Endpoint, error) {
+		if !fs.Parsed() {
+			return nil, errors.New("flags were not parsed")
+		}
+		if config.prefix == "" {
+			return nil, missingFlag("crud-prefix")
+		}
+		if config.storeURI == "" {
+			return nil, missingFlag("crud-store")
+		}
+		config.allowedOrigins = nil
+		for _, origin := range strings.Split(*origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.allowedOrigins = append(config.allowedOrigins, origin)
+			}
+		}
+		config.apiKeys = nil
+		if *apiKeys != "" {
+			if err := json.Unmarshal([]byte(*apiKeys), &config.apiKeys); err != nil {
+				return nil, fmt.Errorf("invalid flag --crud-api-keys: %v", err)
+			}
+		}
+		endpoint, err := config.newEndpoint()
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag configuration: %v", err)
+		}
+		return endpoint, nil
+	}, nil
+}
+
+func missingFlag(name string) error {
+	return fmt.Errorf("flag --%v is required", name)
+}
@@ -0,0 +1,68 @@
+package config_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/trusch/crud/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Flags", func() {
+	var fs *flag.FlagSet
+
+	BeforeEach(func() {
+		fs = flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll("/tmp/test-config")
+	})
+
+	It("should construct an endpoint from the flags", func() {
+		factory, err := NewEndpointFromFlags(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.Parse([]string{"--crud-prefix", "test", "--crud-store", "file:///tmp/test-config", "--crud-read-only", "--crud-api-keys", `{"secret":"alice"}`})).To(Succeed())
+		endpoint, err := factory()
+		Expect(err).NotTo(HaveOccurred())
+		key := http.Header{"X-Api-Key": {"secret"}}
+		Expect(request(endpoint, "GET", "/", "", key).Code).To(Equal(http.StatusOK))
+		Expect(request(endpoint, "PUT", "/key", "{}", key).Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should require a prefix and a store", func() {
+		factory, err := NewEndpointFromFlags(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.Parse([]string{"--crud-prefix", "test"})).To(Succeed())
+		_, err = factory()
+		Expect(err).To(MatchError(ContainSubstring("crud-store")))
+	})
+
+	It("should reject invalid values", func() {
+		factory, err := NewEndpointFromFlags(fs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fs.Parse([]string{"--crud-ttl", "forever"})).NotTo(Succeed())
+		Expect(fs.Parse([]string{"--crud-prefix", "test", "--crud-store", "file:///tmp/test-config", "--crud-api-keys", "{"})).To(Succeed())
+		_, err = factory()
+		Expect(err).To(MatchError(ContainSubstring("crud-api-keys")))
+	})
+
+	It("should fail if the flags were not parsed", func() {
+		factory, err := NewEndpointFromFlags(fs)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = factory()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not register the flags twice", func() {
+		_, err := NewEndpointFromFlags(fs)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewEndpointFromFlags(fs)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,27 @@
+package config_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
+
+func request(handler http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer([]byte(body)))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
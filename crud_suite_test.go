@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 
+	. "github.com/trusch/crud"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -19,6 +21,16 @@ func TestApi(t *testing.T) {
 	RunSpecs(t, "CRUD Suite")
 }
 
+func mustEndpoint(endpoint *Endpoint, err error) *Endpoint {
+	Expect(err).NotTo(HaveOccurred())
+	return endpoint
+}
+
+func mustHandler(handler http.Handler, err error) http.Handler {
+	Expect(err).NotTo(HaveOccurred())
+	return handler
+}
+
 func post(handler http.Handler, path, body string) (code int, data string) {
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", path, bytes.NewBuffer([]byte(body)))
@@ -63,3 +75,13 @@ func del(handler http.Handler, path string) (code int, data string) {
 	data = recorder.Body.String()
 	return
 }
+
+func request(handler http.Handler, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer([]byte(body)))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}